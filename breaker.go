@@ -0,0 +1,233 @@
+package identity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrCircuitOpen is matched by every CircuitOpenError,
+// e.g. errors.Is(err, identity.ErrCircuitOpen).
+var ErrCircuitOpen = errors.New("identity: circuit breaker is open")
+
+// CircuitOpenError is returned without calling the Identity Service
+// while the circuit breaker of the method is open.
+type CircuitOpenError struct {
+	Method     string
+	RetryAfter time.Duration
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("%s for %s, retry after %s", ErrCircuitOpen, e.Method, e.RetryAfter)
+}
+
+func (e *CircuitOpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
+
+// GRPCStatus lets AsProblem and status.Convert treat the error as Unavailable.
+func (e *CircuitOpenError) GRPCStatus() *status.Status {
+	return status.New(codes.Unavailable, e.Error())
+}
+
+// BreakerConfig configures the circuit breaker of a method.
+type BreakerConfig struct {
+	// Disabled turns the breaker off, e.g. for a single method.
+	Disabled bool
+	// FailureThreshold is the number of consecutive failures
+	// opening the circuit, defaults to 5.
+	FailureThreshold int
+	// OpenTimeout is how long the circuit stays open before
+	// probing the service again, defaults to 30 seconds.
+	OpenTimeout time.Duration
+	// HalfOpenMaxCalls is the number of probe calls let through
+	// while half-open, defaults to 1.
+	HalfOpenMaxCalls int
+	// IsFailure tells which errors count as failures, defaults to IsUnavailable.
+	IsFailure func(error) bool
+}
+
+// WithCircuitBreaker guards every unary RPC of the Client with a circuit
+// breaker. Once open, calls fail fast with a *CircuitOpenError instead
+// of adding load, and retries, to an unavailable Identity Service.
+func WithCircuitBreaker(cfg BreakerConfig) Option {
+	return func(o *options) {
+		o.breaker = &cfg
+	}
+}
+
+// WithMethodCircuitBreaker overrides the breaker configuration of a single
+// method, given by its full name, e.g. pb.IdentityService_ValidateSession_FullMethodName.
+func WithMethodCircuitBreaker(method string, cfg BreakerConfig) Option {
+	return func(o *options) {
+		if o.methodBreakers == nil {
+			o.methodBreakers = make(map[string]BreakerConfig)
+		}
+		o.methodBreakers[method] = cfg
+	}
+}
+
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+func (s circuitState) String() string {
+	return [...]string{"closed", "open", "half-open"}[s]
+}
+
+// breakers holds one circuit breaker per method.
+type breakers struct {
+	defaults *BreakerConfig
+	methods  map[string]BreakerConfig
+	logger   zerolog.Logger
+
+	mu       sync.Mutex
+	circuits map[string]*circuit
+}
+
+func newBreakers(defaults *BreakerConfig, methods map[string]BreakerConfig, logger zerolog.Logger) *breakers {
+	return &breakers{
+		defaults: defaults,
+		methods:  methods,
+		logger:   logger,
+		circuits: make(map[string]*circuit),
+	}
+}
+
+// get returns the circuit of the method, or nil if it is not guarded.
+func (b *breakers) get(method string) *circuit {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if c, ok := b.circuits[method]; ok {
+		return c
+	}
+	cfg, ok := b.methods[method]
+	if !ok {
+		if b.defaults == nil {
+			b.circuits[method] = nil
+			return nil
+		}
+		cfg = *b.defaults
+	}
+	var c *circuit
+	if !cfg.Disabled {
+		c = newCircuit(method, cfg, b.logger)
+	}
+	b.circuits[method] = c
+	return c
+}
+
+func (b *breakers) unaryInterceptor(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	c := b.get(method)
+	if c == nil {
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+	if err := c.allow(time.Now()); err != nil {
+		return err
+	}
+	err := invoker(ctx, method, req, reply, cc, opts...)
+	c.record(err, time.Now())
+	return err
+}
+
+type circuit struct {
+	method string
+	cfg    BreakerConfig
+	logger zerolog.Logger
+
+	mu       sync.Mutex
+	state    circuitState
+	failures int
+	openedAt time.Time
+	probes   int
+}
+
+func newCircuit(method string, cfg BreakerConfig, logger zerolog.Logger) *circuit {
+	if cfg.FailureThreshold <= 0 {
+		cfg.FailureThreshold = 5
+	}
+	if cfg.OpenTimeout <= 0 {
+		cfg.OpenTimeout = 30 * time.Second
+	}
+	if cfg.HalfOpenMaxCalls <= 0 {
+		cfg.HalfOpenMaxCalls = 1
+	}
+	if cfg.IsFailure == nil {
+		cfg.IsFailure = IsUnavailable
+	}
+	return &circuit{method: method, cfg: cfg, logger: logger}
+}
+
+// allow reports a *CircuitOpenError if the call must not be made.
+func (c *circuit) allow(now time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.state == circuitOpen {
+		reopen := c.openedAt.Add(c.cfg.OpenTimeout)
+		if now.Before(reopen) {
+			return &CircuitOpenError{Method: c.method, RetryAfter: reopen.Sub(now)}
+		}
+		c.transition(circuitHalfOpen)
+		c.probes = 0
+	}
+	if c.state == circuitHalfOpen {
+		if c.probes >= c.cfg.HalfOpenMaxCalls {
+			return &CircuitOpenError{Method: c.method, RetryAfter: time.Second}
+		}
+		c.probes++
+	}
+	return nil
+}
+
+// record updates the circuit with the outcome of an allowed call.
+func (c *circuit) record(err error, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	failed := err != nil && c.cfg.IsFailure(err)
+	switch c.state {
+	case circuitClosed:
+		if !failed {
+			c.failures = 0
+			return
+		}
+		c.failures++
+		if c.failures >= c.cfg.FailureThreshold {
+			c.open(now)
+		}
+	case circuitHalfOpen:
+		c.probes--
+		if failed {
+			c.open(now)
+			return
+		}
+		c.failures = 0
+		c.transition(circuitClosed)
+	}
+}
+
+func (c *circuit) open(now time.Time) {
+	c.openedAt = now
+	c.transition(circuitOpen)
+}
+
+func (c *circuit) transition(to circuitState) {
+	if c.state == to {
+		return
+	}
+	c.logger.Warn().Str("method", c.method).Msgf("circuit breaker %s -> %s", c.state, to)
+	c.state = to
+}
@@ -0,0 +1,202 @@
+package identity
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"sync"
+	"time"
+)
+
+// CacheConfig enables the local session validation cache.
+type CacheConfig struct {
+	// TTL is how long a valid session is cached. It is
+	// capped by the expiry of the session. Defaults to 1 minute.
+	TTL time.Duration
+	// NegativeTTL is how long an invalid token is remembered.
+	// Defaults to 10 seconds, a negative value disables negative caching.
+	NegativeTTL time.Duration
+	// MaxEntries bounds the cache size, the least recently
+	// used entries are evicted first. Defaults to 10000.
+	MaxEntries int
+	// SharedTimeout bounds the RPC shared by the concurrent validations
+	// of a token. It runs detached from the caller that started it, so
+	// a cancelled request does not fail the others. Defaults to 10 seconds.
+	SharedTimeout time.Duration
+}
+
+// WithSessionCache caches the ValidateSession results in memory.
+// Concurrent validations of the same token share a single RPC.
+func WithSessionCache(cfg CacheConfig) Option {
+	return func(o *options) {
+		o.cache = &cfg
+	}
+}
+
+// tokenKey is the cache key of a token, so raw tokens are never kept in memory.
+type tokenKey [sha256.Size]byte
+
+func keyOf(token string) tokenKey {
+	return sha256.Sum256([]byte(token))
+}
+
+type cacheEntry struct {
+	key     tokenKey
+	result  *SessionResult
+	err     error
+	expires time.Time
+}
+
+// sessionCache is an LRU of validation results with per-entry expiry.
+type sessionCache struct {
+	cfg CacheConfig
+	// staleGrace keeps the valid sessions past their TTL,
+	// to be served while the Identity Service is unavailable.
+	staleGrace time.Duration
+
+	mu      sync.Mutex
+	ll      *list.List
+	entries map[tokenKey]*list.Element
+	// gen is bumped on every revocation, so validations
+	// started before it are not cached afterwards.
+	gen uint64
+}
+
+func newSessionCache(cfg CacheConfig, staleGrace time.Duration) *sessionCache {
+	if cfg.TTL <= 0 {
+		cfg.TTL = time.Minute
+	}
+	if cfg.NegativeTTL == 0 {
+		cfg.NegativeTTL = 10 * time.Second
+	}
+	if cfg.MaxEntries <= 0 {
+		cfg.MaxEntries = 10000
+	}
+	if cfg.SharedTimeout <= 0 {
+		cfg.SharedTimeout = 10 * time.Second
+	}
+	return &sessionCache{
+		cfg:        cfg,
+		staleGrace: staleGrace,
+		ll:         list.New(),
+		entries:    make(map[tokenKey]*list.Element),
+	}
+}
+
+// get returns the cached entry of the token, if any and not expired.
+func (c *sessionCache) get(key tokenKey, now time.Time) (*cacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	e := el.Value.(*cacheEntry)
+	if !now.Before(e.expires) {
+		if !e.servableStale(now, c.staleGrace) {
+			c.remove(el)
+		}
+		return nil, false
+	}
+	c.ll.MoveToFront(el)
+	return e, true
+}
+
+// getStale returns the valid session of the token past its TTL,
+// if it is still within the grace window and not expired.
+func (c *sessionCache) getStale(key tokenKey, now time.Time) (*SessionResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	e := el.Value.(*cacheEntry)
+	if !e.servableStale(now, c.staleGrace) {
+		return nil, false
+	}
+	return e.result, true
+}
+
+func (e *cacheEntry) servableStale(now time.Time, grace time.Duration) bool {
+	if e.result == nil || !now.Before(e.expires.Add(grace)) {
+		return false
+	}
+	return e.result.ExpiresAt.IsZero() || now.Before(e.result.ExpiresAt)
+}
+
+// generation returns the current revocation generation.
+func (c *sessionCache) generation() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.gen
+}
+
+// addValid caches a valid session until the TTL or the session expiry, whichever comes first.
+// The result is dropped if a revocation happened since the generation was read.
+func (c *sessionCache) addValid(key tokenKey, res *SessionResult, now time.Time, gen uint64) {
+	expires := now.Add(c.cfg.TTL)
+	if !res.ExpiresAt.IsZero() && res.ExpiresAt.Before(expires) {
+		expires = res.ExpiresAt
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.gen != gen {
+		return
+	}
+	c.addLocked(&cacheEntry{key: key, result: res, expires: expires})
+}
+
+// addInvalid remembers that the token was rejected by the Identity Service.
+func (c *sessionCache) addInvalid(key tokenKey, err error, now time.Time) {
+	if c.cfg.NegativeTTL < 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.addLocked(&cacheEntry{key: key, err: err, expires: now.Add(c.cfg.NegativeTTL)})
+}
+
+func (c *sessionCache) addLocked(e *cacheEntry) {
+	if el, ok := c.entries[e.key]; ok {
+		el.Value = e
+		c.ll.MoveToFront(el)
+		return
+	}
+	c.entries[e.key] = c.ll.PushFront(e)
+	for c.ll.Len() > c.cfg.MaxEntries {
+		c.remove(c.ll.Back())
+	}
+}
+
+func (c *sessionCache) remove(el *list.Element) {
+	c.ll.Remove(el)
+	delete(c.entries, el.Value.(*cacheEntry).key)
+}
+
+// revokeToken drops the cached session of the token.
+func (c *sessionCache) revokeToken(key tokenKey) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.gen++
+	if el, ok := c.entries[key]; ok {
+		c.remove(el)
+	}
+}
+
+// revokeUser drops every cached session of the user.
+func (c *sessionCache) revokeUser(userID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.gen++
+	for el := c.ll.Front(); el != nil; {
+		next := el.Next()
+		if e := el.Value.(*cacheEntry); e.result != nil && e.result.User.GetId() == userID {
+			c.remove(el)
+		}
+		el = next
+	}
+}
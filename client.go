@@ -2,19 +2,33 @@ package identity
 
 import (
 	"context"
+	"errors"
+	"slices"
 	"time"
 
 	pb "github.com/kodeart/identity-sdk-go/proto/v1"
-	"github.com/rs/zerolog/log"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/singleflight"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/connectivity"
-	"google.golang.org/grpc/credentials/insecure"
-	"google.golang.org/grpc/keepalive"
+	"google.golang.org/protobuf/proto"
 )
 
 type Client struct {
 	grpcsvc pb.IdentityServiceClient
 	conn    *grpc.ClientConn
+
+	cache    *sessionCache
+	inflight singleflight.Group
+
+	stopWatch context.CancelFunc
+	watchDone chan struct{}
+
+	logger  zerolog.Logger
+	metrics Metrics
+	tracer  trace.Tracer
 }
 
 // NewClient creates a new gRPC "channel" for the target URI provided.
@@ -25,32 +39,19 @@ type Client struct {
 // name resolver will be used if no scheme is detected, or if the parsed scheme
 // is not a registered name resolver. The default resolver is "dns" but can be overridden.
 //
-// The authority is a value to be used as the :authority pseudo-header and as
-// the server name in authentication handshake. This overrides all other ways
-// of setting authority on the channel, but can be overridden per-call by using grpc.CallAuthority.
-func NewClient(target, authority string) (*Client, error) {
-	opts := []grpc.DialOption{
-		grpc.WithAuthority(authority),
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-		grpc.WithKeepaliveParams(keepalive.ClientParameters{
-			Time:                10 * time.Second,
-			Timeout:             time.Second,
-			PermitWithoutStream: true,
-		}),
-		grpc.WithDefaultServiceConfig(`{
-        "methodConfig": [{
-            "name": [{"service": ""}], 
-            "retryPolicy": {
-                "maxAttempts": 5,
-                "initialBackoff": "0.1s",
-                "maxBackoff": "1s",
-                "backoffMultiplier": 2.0,
-                "retryableStatusCodes": ["UNAVAILABLE"]
-            }
-        }]}`),
-	}
-	log.Info().Msgf("connecting to Identity Service at %s", target)
-	conn, err := grpc.NewClient(target, opts...)
+// Without options the channel is plaintext, uses the DefaultKeepalive
+// parameters and retries UNAVAILABLE calls with the DefaultRetryPolicy.
+func NewClient(target string, opts ...Option) (*Client, error) {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+	dialOpts, err := o.dialOptions()
+	if err != nil {
+		return nil, err
+	}
+	o.logger.Info().Msgf("connecting to Identity Service at %s", target)
+	conn, err := grpc.NewClient(target, dialOpts...)
 	if err != nil {
 		return nil, err
 	}
@@ -59,25 +60,58 @@ func NewClient(target, authority string) (*Client, error) {
 	conn.Connect()
 
 	// Some sanity check
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
-	defer cancel()
+	if o.connectTimeout > 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), o.connectTimeout)
+		defer cancel()
 
-	if !conn.WaitForStateChange(ctx, connectivity.Ready) {
-		log.Warn().Msgf("Identity Service not ready yet, proceeding in background...")
-	} else {
-		log.Info().Msgf("Identity Service connection established")
+		if !waitForReady(ctx, conn) {
+			o.logger.Warn().Msgf("Identity Service not ready yet, proceeding in background...")
+		} else {
+			o.logger.Info().Msgf("Identity Service connection established")
+		}
 	}
 
-	return &Client{
+	c := &Client{
 		grpcsvc: pb.NewIdentityServiceClient(conn),
 		conn:    conn,
-	}, nil
+		logger:  o.logger,
+		metrics: o.metrics,
+		tracer:  o.tracer(),
+	}
+	if o.cache != nil {
+		c.cache = newSessionCache(*o.cache, o.degradation.StaleGrace)
+		c.startRevocationWatch()
+	}
+	return c, nil
+}
+
+// NewClientWithAuthority is the two-argument form of NewClient.
+//
+// The authority is a value to be used as the :authority pseudo-header and as
+// the server name in authentication handshake. This overrides all other ways
+// of setting authority on the channel, but can be overridden per-call by using grpc.CallAuthority.
+func NewClientWithAuthority(target, authority string) (*Client, error) {
+	return NewClient(target, WithAuthority(authority))
+}
+
+// waitForReady blocks until the connection is ready or the context expires.
+func waitForReady(ctx context.Context, conn *grpc.ClientConn) bool {
+	for {
+		state := conn.GetState()
+		if state == connectivity.Ready {
+			return true
+		}
+		if !conn.WaitForStateChange(ctx, state) {
+			return false
+		}
+	}
 }
 
 // AuthenticateWithProvider is what the frontend calls
 // after getting a token from the external auth provider.
 func (c *Client) AuthenticateWithProvider(ctx context.Context, tenantSlug, providerToken string) (*pb.AuthenticateResponse, error) {
-	return c.grpcsvc.Authenticate(ctx, &pb.AuthenticateRequest{
+	c.logger.Debug().Str("tenant", tenantSlug).Str("provider_token", redactToken(providerToken)).Msg("authenticate with provider token...")
+	return c.authenticate(ctx, "provider", &pb.AuthenticateRequest{
 		TenantSlug: tenantSlug,
 		Credentials: &pb.AuthenticateRequest_ProviderToken{
 			ProviderToken: providerToken,
@@ -86,7 +120,8 @@ func (c *Client) AuthenticateWithProvider(ctx context.Context, tenantSlug, provi
 }
 
 func (c *Client) AuthenticateWithCredentials(ctx context.Context, tenantSlug, email, password string) (*pb.AuthenticateResponse, error) {
-	return c.grpcsvc.Authenticate(ctx, &pb.AuthenticateRequest{
+	c.logger.Debug().Str("tenant", tenantSlug).Str("email", email).Str("password", redact(password)).Msg("authenticate with credentials...")
+	return c.authenticate(ctx, "password", &pb.AuthenticateRequest{
 		TenantSlug: tenantSlug,
 		Credentials: &pb.AuthenticateRequest_Credential{
 			Credential: &pb.UserCredentials{
@@ -97,21 +132,162 @@ func (c *Client) AuthenticateWithCredentials(ctx context.Context, tenantSlug, em
 	})
 }
 
+func (c *Client) authenticate(ctx context.Context, credentials string, req *pb.AuthenticateRequest) (resp *pb.AuthenticateResponse, err error) {
+	ctx, span := c.tracer.Start(ctx, "identity.Authenticate",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			AttrMethod.String(pb.IdentityService_Authenticate_FullMethodName),
+			AttrTenant.String(req.GetTenantSlug()),
+			attribute.String("identity.credentials", credentials),
+		))
+	defer func() { endSpan(span, err) }()
+
+	return c.grpcsvc.Authenticate(ctx, req)
+}
+
+// RefreshSession exchanges the refresh token for a new session.
+// See TokenSource to keep a session alive.
+func (c *Client) RefreshSession(ctx context.Context, refreshToken string) (*pb.AuthenticateResponse, error) {
+	c.logger.Debug().Str("refresh_token", redactToken(refreshToken)).Msg("refresh session...")
+	return c.grpcsvc.RefreshSession(ctx, &pb.RefreshSessionRequest{RefreshToken: refreshToken})
+}
+
+// SessionValidator resolves a session token to its SessionResult.
+// It is implemented by the Client and the Verifier.
+type SessionValidator interface {
+	ValidateSession(ctx context.Context, token string) (*SessionResult, error)
+}
+
+// SessionResult is the outcome of a successful session validation.
+type SessionResult struct {
+	Valid     bool
+	User      *pb.User
+	ExpiresAt time.Time
+	Reason    string
+	SessionID string
+	Scopes    []string
+	// Degraded is set when the session was served from the stale
+	// cache because the Identity Service is unavailable.
+	Degraded bool
+}
+
 // ValidateSession is used by the middleware
 // to check if the JWT from the request is valid.
-func (c *Client) ValidateSession(ctx context.Context, token string) (*pb.User, error) {
-	log.Debug().Str("token", token).Msg("verify user token...")
+//
+// A token the Identity Service does not consider valid is
+// reported as an *InvalidSessionError, never as a SessionResult.
+func (c *Client) ValidateSession(ctx context.Context, token string) (res *SessionResult, err error) {
+	ctx, span := c.tracer.Start(ctx, "identity.ValidateSession",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(AttrMethod.String(pb.IdentityService_ValidateSession_FullMethodName)))
+	defer func() {
+		if res != nil {
+			span.SetAttributes(AttrTenant.String(res.User.GetTenantId()))
+		}
+		endSpan(span, err)
+	}()
+
+	if c.cache == nil {
+		return c.validateSession(ctx, token)
+	}
+	key := keyOf(token)
+	if e, ok := c.cache.get(key, time.Now()); ok {
+		c.metrics.ObserveCache(CacheHit)
+		span.SetAttributes(AttrCache.String(string(CacheHit)))
+		return e.result.clone(), e.err
+	}
+	c.metrics.ObserveCache(CacheMiss)
+	span.SetAttributes(AttrCache.String(string(CacheMiss)))
+	ch := c.inflight.DoChan(string(key[:]), func() (any, error) {
+		sctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), c.cache.cfg.SharedTimeout)
+		defer cancel()
+
+		gen := c.cache.generation()
+		res, err := c.validateSession(sctx, token)
+		var invalid *InvalidSessionError
+		switch {
+		case err == nil:
+			c.cache.addValid(key, res, time.Now(), gen)
+		case errors.As(err, &invalid):
+			c.cache.addInvalid(key, err, time.Now())
+		}
+		return res, err
+	})
+	var v any
+	select {
+	case r := <-ch:
+		v, err = r.Val, r.Err
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	if IsUnavailable(err) && c.cache.staleGrace > 0 {
+		if res, ok := c.cache.getStale(key, time.Now()); ok {
+			c.metrics.ObserveCache(CacheStale)
+			span.SetAttributes(AttrCache.String(string(CacheStale)))
+			c.logger.Warn().Err(err).Str("token", redactToken(token)).Msg("Identity Service unavailable, serving the session from the stale cache")
+			res = res.clone()
+			res.Degraded = true
+			return res, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return v.(*SessionResult).clone(), nil
+}
+
+func (c *Client) validateSession(ctx context.Context, token string) (*SessionResult, error) {
+	c.logger.Debug().Str("token", redactToken(token)).Msg("verify user token...")
 
 	resp, err := c.grpcsvc.ValidateSession(ctx, &pb.ValidateSessionRequest{Token: token})
-	return resp.User, err
-	/*
-	   if err != nil || !resp.Valid {
-	       return nil, err
-	   }
-	   return resp.User, nil
-	*/
+	if err != nil {
+		return nil, err
+	}
+	return sessionResult(resp, time.Now())
+}
+
+// clone returns a deep copy, so callers sharing a cached result,
+// and the cache itself, cannot see each other's modifications.
+func (r *SessionResult) clone() *SessionResult {
+	if r == nil {
+		return nil
+	}
+	cp := *r
+	if r.User != nil {
+		cp.User = proto.Clone(r.User).(*pb.User)
+	}
+	cp.Scopes = slices.Clone(r.Scopes)
+	return &cp
+}
+
+// sessionResult checks the response and converts it to a SessionResult.
+func sessionResult(resp *pb.ValidateSessionResponse, now time.Time) (*SessionResult, error) {
+	if resp == nil || !resp.GetValid() {
+		return nil, &InvalidSessionError{Reason: resp.GetReason()}
+	}
+	if resp.GetUser() == nil {
+		return nil, &InvalidSessionError{Reason: "no user in session"}
+	}
+	res := &SessionResult{
+		Valid:     true,
+		User:      resp.GetUser(),
+		Reason:    resp.GetReason(),
+		SessionID: resp.GetSessionId(),
+		Scopes:    resp.GetScopes(),
+	}
+	if resp.GetExpiresAt() != nil {
+		res.ExpiresAt = resp.GetExpiresAt().AsTime()
+		if !res.ExpiresAt.After(now) {
+			return nil, &InvalidSessionError{Reason: "session expired"}
+		}
+	}
+	return res, nil
 }
 
 func (c *Client) Close() error {
+	if c.stopWatch != nil {
+		c.stopWatch()
+		<-c.watchDone
+	}
 	return c.conn.Close()
 }
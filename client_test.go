@@ -0,0 +1,152 @@
+package identity
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	pb "github.com/kodeart/identity-sdk-go/proto/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestValidateSessionResult(t *testing.T) {
+	now := time.Now()
+	responses := map[string]*pb.ValidateSessionResponse{
+		"valid": {
+			Valid:     true,
+			User:      &pb.User{Id: "u1"},
+			SessionId: "s1",
+			Scopes:    []string{"openid"},
+			ExpiresAt: timestamppb.New(now.Add(time.Hour)),
+		},
+		"revoked": {Valid: false, Reason: "session revoked"},
+		"no-user": {Valid: true},
+		"expired": {Valid: true, User: &pb.User{Id: "u1"}, ExpiresAt: timestamppb.New(now.Add(-time.Second))},
+	}
+	addr := startServer(t, &fakeIdentityService{
+		validateSession: func(_ context.Context, req *pb.ValidateSessionRequest) (*pb.ValidateSessionResponse, error) {
+			return responses[req.GetToken()], nil
+		},
+	})
+	c, err := NewClient(addr, WithoutRetry())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	res, err := c.ValidateSession(context.Background(), "valid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Valid || res.User.GetId() != "u1" || res.SessionID != "s1" || len(res.Scopes) != 1 ||
+		!res.ExpiresAt.Equal(responses["valid"].GetExpiresAt().AsTime()) {
+		t.Fatalf("unexpected result %+v", res)
+	}
+
+	tests := []struct {
+		token  string
+		reason string
+	}{
+		{"revoked", "session revoked"},
+		{"no-user", "no user in session"},
+		{"expired", "session expired"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.token, func(t *testing.T) {
+			res, err := c.ValidateSession(context.Background(), tt.token)
+			var invalid *InvalidSessionError
+			if res != nil || !errors.Is(err, ErrInvalidSession) || !errors.As(err, &invalid) || invalid.Reason != tt.reason {
+				t.Fatalf("got %+v, %v, want an InvalidSessionError %q", res, err, tt.reason)
+			}
+		})
+	}
+}
+
+func TestSessionResultNilResponse(t *testing.T) {
+	if res, err := sessionResult(nil, time.Now()); res != nil || !errors.Is(err, ErrInvalidSession) {
+		t.Fatalf("got %+v, %v, want ErrInvalidSession", res, err)
+	}
+}
+
+func TestValidateSessionSharedCallSurvivesCancellation(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	addr := startServer(t, &fakeIdentityService{
+		validateSession: func(ctx context.Context, _ *pb.ValidateSessionRequest) (*pb.ValidateSessionResponse, error) {
+			calls.Add(1)
+			select {
+			case <-release:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+			return &pb.ValidateSessionResponse{Valid: true, User: &pb.User{Id: "u1"}}, nil
+		},
+	})
+	c, err := NewClient(addr, WithSessionCache(CacheConfig{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	first, cancel := context.WithCancel(context.Background())
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err := c.ValidateSession(first, "token")
+		firstErr <- err
+	}()
+	for calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	second := make(chan error, 1)
+	go func() {
+		_, err := c.ValidateSession(context.Background(), "token")
+		second <- err
+	}()
+
+	cancel()
+	if err := <-firstErr; !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled caller got %v, want context.Canceled", err)
+	}
+	close(release)
+	if err := <-second; err != nil {
+		t.Fatalf("concurrent caller failed with the cancellation of the first one: %v", err)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("%d calls of the Identity Service, want 1 shared call", n)
+	}
+}
+
+func TestValidateSessionCachedResultIsolated(t *testing.T) {
+	addr := startServer(t, &fakeIdentityService{
+		validateSession: func(context.Context, *pb.ValidateSessionRequest) (*pb.ValidateSessionResponse, error) {
+			return &pb.ValidateSessionResponse{
+				Valid:  true,
+				User:   &pb.User{Id: "u1", Roles: []string{"viewer"}},
+				Scopes: []string{"openid"},
+			}, nil
+		},
+	})
+	c, err := NewClient(addr, WithSessionCache(CacheConfig{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	first, err := c.ValidateSession(context.Background(), "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	first.User.Roles = append(first.User.Roles, "admin")
+	first.User.DisplayName = "changed"
+	first.Scopes[0] = "admin"
+
+	second, err := c.ValidateSession(context.Background(), "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(second.User.GetRoles()) != 1 || second.User.GetDisplayName() != "" || second.Scopes[0] != "openid" {
+		t.Fatalf("the cached result was modified by a caller: %+v", second)
+	}
+}
@@ -10,20 +10,54 @@ type contextKey string
 
 const UserContextKey contextKey = "identity"
 
+const anonymousContextKey contextKey = "identity-anonymous"
+
+// WithUser returns a copy of the context carrying the authenticated user.
+func WithUser(ctx context.Context, u *pb.User) context.Context {
+	return context.WithValue(ctx, UserContextKey, u)
+}
+
+// UserFromContext retrieves the authenticated user from a request context.
+// It reports false if the request is not authenticated.
+func UserFromContext(ctx context.Context) (*pb.User, bool) {
+	user, ok := ctx.Value(UserContextKey).(*pb.User)
+	return user, ok && user != nil
+}
+
+// MustUser retrieves the authenticated user from a request context.
+// It panics if the request is not authenticated, so it is meant for
+// handlers that are always mounted behind the IdentityAuth middleware.
+func MustUser(ctx context.Context) *pb.User {
+	user, ok := UserFromContext(ctx)
+	if !ok {
+		panic("identity: no authenticated user in context")
+	}
+	return user
+}
+
 // GetUser is a helper to retrieve the authenticated user from a request context.
+// It returns nil if the request is not authenticated.
+//
+// Deprecated: use UserFromContext, which makes the missing user explicit.
 func GetUser(ctx context.Context) *pb.User {
-	if user, ok := ctx.Value(UserContextKey).(*pb.User); ok {
-		return user
-	}
-	u := &pb.User{
-		Id:          "42",
-		Email:       "foo@example.com",
-		TenantId:    "bogus",
-		DisplayName: "Anonymous",
-		Metadata:    nil,
-		LastLogin:   nil,
-		CreatedAt:   nil,
-	}
-	ctx = context.WithValue(ctx, UserContextKey, u)
-	return u
+	user, _ := UserFromContext(ctx)
+	return user
+}
+
+// Anonymous is the principal of a request that was deliberately let through
+// without authentication. It is never returned by UserFromContext.
+type Anonymous struct {
+	// Reason documents why the request is anonymous, e.g. "public endpoint".
+	Reason string
+}
+
+// WithAnonymous marks the request as deliberately unauthenticated.
+func WithAnonymous(ctx context.Context, a Anonymous) context.Context {
+	return context.WithValue(ctx, anonymousContextKey, a)
+}
+
+// AnonymousFromContext reports whether the request was marked as anonymous.
+func AnonymousFromContext(ctx context.Context) (Anonymous, bool) {
+	a, ok := ctx.Value(anonymousContextKey).(Anonymous)
+	return a, ok
 }
@@ -0,0 +1,112 @@
+package identity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/credentials"
+)
+
+// APIKeyHeader is the metadata key used by StaticAPIKey.
+const APIKeyHeader = "x-api-key"
+
+// WithPerRPCCredentials attaches the service identity to every call
+// made by the Client, e.g. the admin RPCs CreateUser or CreateTenant.
+func WithPerRPCCredentials(creds credentials.PerRPCCredentials) Option {
+	return func(o *options) {
+		o.perRPC = creds
+	}
+}
+
+// AllowInsecurePerRPCCredentials permits sending the per-RPC credentials
+// over a plaintext channel. Use only for local development.
+func AllowInsecurePerRPCCredentials() Option {
+	return func(o *options) {
+		o.insecurePerRPC = true
+	}
+}
+
+// StaticAPIKey sends the same key in the "x-api-key" metadata on every call.
+func StaticAPIKey(key string) credentials.PerRPCCredentials {
+	return PerRPCCredentialsFunc(func(context.Context) (map[string]string, error) {
+		return map[string]string{APIKeyHeader: key}, nil
+	})
+}
+
+// PerRPCCredentialsFunc is a callback returning the metadata
+// to attach to the outgoing call.
+type PerRPCCredentialsFunc func(ctx context.Context) (map[string]string, error)
+
+func (fn PerRPCCredentialsFunc) GetRequestMetadata(ctx context.Context, _ ...string) (map[string]string, error) {
+	return fn(ctx)
+}
+
+func (fn PerRPCCredentialsFunc) RequireTransportSecurity() bool {
+	return true
+}
+
+// BearerTokenFile sends the token stored in the file as "authorization: Bearer <token>".
+// The file is re-read whenever it changes, so tokens projected by
+// the orchestrator (e.g. Kubernetes service account tokens) are rotated transparently.
+func BearerTokenFile(path string) credentials.PerRPCCredentials {
+	return &fileTokenCredentials{path: path}
+}
+
+type fileTokenCredentials struct {
+	path string
+
+	mu      sync.Mutex
+	modTime time.Time
+	size    int64
+	token   string
+}
+
+func (f *fileTokenCredentials) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	token, err := f.load()
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"authorization": "Bearer " + token}, nil
+}
+
+func (f *fileTokenCredentials) RequireTransportSecurity() bool {
+	return true
+}
+
+// load returns the cached token, re-reading the file if it changed.
+func (f *fileTokenCredentials) load() (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	fi, err := os.Stat(f.path)
+	if err != nil {
+		return "", fmt.Errorf("identity: %w", err)
+	}
+	if f.token != "" && fi.ModTime().Equal(f.modTime) && fi.Size() == f.size {
+		return f.token, nil
+	}
+	b, err := os.ReadFile(f.path)
+	if err != nil {
+		return "", fmt.Errorf("identity: %w", err)
+	}
+	token := strings.TrimSpace(string(b))
+	if token == "" {
+		return "", errors.New("identity: token file " + f.path + " is empty")
+	}
+	f.token, f.modTime, f.size = token, fi.ModTime(), fi.Size()
+	return token, nil
+}
+
+// insecurePerRPC lifts the transport security requirement of the wrapped credentials.
+type insecurePerRPC struct {
+	credentials.PerRPCCredentials
+}
+
+func (insecurePerRPC) RequireTransportSecurity() bool {
+	return false
+}
@@ -0,0 +1,292 @@
+package identity
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	pb "github.com/kodeart/identity-sdk-go/proto/v1"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// ErrSchemaMismatch is matched by every SchemaError,
+// e.g. errors.Is(err, identity.ErrSchemaMismatch).
+var ErrSchemaMismatch = errors.New("identity: schema mismatch")
+
+// SchemaError reports user metadata or tenant settings
+// not matching the Go type or the JSON Schema they are decoded with.
+type SchemaError struct {
+	// Source is "user metadata" or "tenant settings".
+	Source string
+	// Field is the dotted path of the mismatching value, empty for the whole document.
+	Field  string
+	Reason string
+	// Err is the underlying encoding/json or jsonschema error.
+	Err error
+}
+
+func (e *SchemaError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("identity: %s: %s", e.Source, e.Reason)
+	}
+	return fmt.Sprintf("identity: %s: field %q: %s", e.Source, e.Field, e.Reason)
+}
+
+func (e *SchemaError) Is(target error) bool {
+	return target == ErrSchemaMismatch
+}
+
+func (e *SchemaError) Unwrap() error {
+	return e.Err
+}
+
+// DecodeOption configures DecodeMetadata and DecodeSettings.
+type DecodeOption func(*decodeConfig)
+
+type decodeConfig struct {
+	strict bool
+	schema *Schema
+}
+
+// Strict rejects the fields that the Go type does not declare.
+func Strict() DecodeOption {
+	return func(c *decodeConfig) {
+		c.strict = true
+	}
+}
+
+// WithSchema validates the document against the JSON Schema before decoding it.
+func WithSchema(s *Schema) DecodeOption {
+	return func(c *decodeConfig) {
+		c.schema = s
+	}
+}
+
+// Schema is a compiled JSON Schema, e.g. of the tenant settings,
+// so every service validates the same way.
+type Schema struct {
+	schema *jsonschema.Schema
+}
+
+// CompileSchema compiles the JSON Schema document.
+func CompileSchema(schema []byte) (*Schema, error) {
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(schema))
+	if err != nil {
+		return nil, fmt.Errorf("identity: %w", err)
+	}
+	c := jsonschema.NewCompiler()
+	if err := c.AddResource("schema.json", doc); err != nil {
+		return nil, fmt.Errorf("identity: %w", err)
+	}
+	s, err := c.Compile("schema.json")
+	if err != nil {
+		return nil, fmt.Errorf("identity: %w", err)
+	}
+	return &Schema{schema: s}, nil
+}
+
+// Validate checks a document against the schema, e.g. the
+// Metadata of a User or the Settings of a Tenant. A nil document
+// is validated as an empty object.
+func (s *Schema) Validate(doc map[string]any) error {
+	st, err := mapSource("document", doc)
+	if err != nil {
+		return err
+	}
+	return s.validate("document", st)
+}
+
+// ValidateSettings checks the tenant settings against the schema.
+// Unset settings are validated as an empty object.
+func (s *Schema) ValidateSettings(t *pb.Tenant) error {
+	return s.validate("tenant settings", t.GetSettings())
+}
+
+// ValidateMetadata checks the user metadata against the schema.
+// Unset metadata is validated as an empty object.
+func (s *Schema) ValidateMetadata(u *pb.User) error {
+	return s.validate("user metadata", u.GetMetadata())
+}
+
+func (s *Schema) validate(source string, doc *structpb.Struct) error {
+	data, err := structJSON(doc)
+	if err != nil {
+		return err
+	}
+	v, err := jsonschema.UnmarshalJSON(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	err = s.schema.Validate(v)
+	var ve *jsonschema.ValidationError
+	if !errors.As(err, &ve) {
+		return err
+	}
+	for len(ve.Causes) > 0 {
+		ve = ve.Causes[0]
+	}
+	return &SchemaError{
+		Source: source,
+		Field:  strings.Join(ve.InstanceLocation, "."),
+		Reason: reason(ve),
+		Err:    err,
+	}
+}
+
+// MetadataSource is a user, or its metadata, accepted by DecodeMetadata.
+type MetadataSource interface {
+	*pb.User | *User | map[string]any
+}
+
+// SettingsSource is a tenant, or its settings, accepted by DecodeSettings.
+type SettingsSource interface {
+	*pb.Tenant | *Tenant | map[string]any
+}
+
+// DecodeMetadata decodes the user metadata into T through its JSON tags.
+// Unset metadata decodes to the zero T.
+//
+//	prefs, err := identity.DecodeMetadata[Prefs](user)
+func DecodeMetadata[T any, S MetadataSource](src S, opts ...DecodeOption) (T, error) {
+	const source = "user metadata"
+	var st *structpb.Struct
+	switch src := any(src).(type) {
+	case *pb.User:
+		st = src.GetMetadata()
+	case *User:
+		if src != nil {
+			return decodeMap[T](source, src.Metadata, opts)
+		}
+	case map[string]any:
+		return decodeMap[T](source, src, opts)
+	}
+	return decodeStruct[T](source, st, opts)
+}
+
+// DecodeSettings decodes the tenant settings into T through its JSON tags.
+// Unset settings decode to the zero T.
+func DecodeSettings[T any, S SettingsSource](src S, opts ...DecodeOption) (T, error) {
+	const source = "tenant settings"
+	var st *structpb.Struct
+	switch src := any(src).(type) {
+	case *pb.Tenant:
+		st = src.GetSettings()
+	case *Tenant:
+		if src != nil {
+			return decodeMap[T](source, src.Settings, opts)
+		}
+	case map[string]any:
+		return decodeMap[T](source, src, opts)
+	}
+	return decodeStruct[T](source, st, opts)
+}
+
+func decodeMap[T any](source string, m map[string]any, opts []DecodeOption) (T, error) {
+	st, err := mapSource(source, m)
+	if err != nil {
+		var out T
+		return out, err
+	}
+	return decodeStruct[T](source, st, opts)
+}
+
+// mapSource converts a document of the SDK models, nil stays unset.
+func mapSource(source string, m map[string]any) (*structpb.Struct, error) {
+	st, err := mapStruct(m)
+	if err != nil {
+		return nil, &SchemaError{Source: source, Reason: err.Error(), Err: err}
+	}
+	return st, nil
+}
+
+// EncodeMetadata encodes v through its JSON tags, e.g. for pb.CreateUserRequest.
+// It must encode to a JSON object.
+func EncodeMetadata(v any) (*structpb.Struct, error) {
+	return encodeStruct("user metadata", v)
+}
+
+// EncodeSettings is the tenant settings counterpart of EncodeMetadata.
+func EncodeSettings(v any) (*structpb.Struct, error) {
+	return encodeStruct("tenant settings", v)
+}
+
+func decodeStruct[T any](source string, s *structpb.Struct, opts []DecodeOption) (T, error) {
+	var out T
+	cfg := &decodeConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if cfg.schema != nil {
+		if err := cfg.schema.validate(source, s); err != nil {
+			return out, err
+		}
+	}
+	if s == nil {
+		return out, nil
+	}
+	data, err := structJSON(s)
+	if err != nil {
+		return out, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if cfg.strict {
+		dec.DisallowUnknownFields()
+	}
+	if err := dec.Decode(&out); err != nil {
+		return out, schemaError(source, err)
+	}
+	return out, nil
+}
+
+func encodeStruct(source string, v any) (*structpb.Struct, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	s := &structpb.Struct{}
+	if err := s.UnmarshalJSON(data); err != nil {
+		return nil, &SchemaError{Source: source, Reason: "must encode to a JSON object", Err: err}
+	}
+	return s, nil
+}
+
+// structJSON encodes an unset struct as an empty object.
+func structJSON(s *structpb.Struct) ([]byte, error) {
+	if s == nil {
+		return []byte("{}"), nil
+	}
+	return s.MarshalJSON()
+}
+
+// reason describes the innermost validation error, without its location.
+func reason(ve *jsonschema.ValidationError) string {
+	if out := ve.DetailedOutput(); out.Error != nil {
+		return out.Error.String()
+	}
+	return ve.Error()
+}
+
+// schemaError converts the encoding/json decoding errors.
+func schemaError(source string, err error) error {
+	var te *json.UnmarshalTypeError
+	if errors.As(err, &te) {
+		return &SchemaError{
+			Source: source,
+			Field:  te.Field,
+			Reason: fmt.Sprintf("got a JSON %s, want %s", te.Value, te.Type),
+			Err:    err,
+		}
+	}
+	if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		return &SchemaError{
+			Source: source,
+			Field:  strings.Trim(field, `"`),
+			Reason: "unknown field",
+			Err:    err,
+		}
+	}
+	return &SchemaError{Source: source, Reason: err.Error(), Err: err}
+}
@@ -0,0 +1,69 @@
+package identity
+
+import (
+	"errors"
+	"testing"
+
+	pb "github.com/kodeart/identity-sdk-go/proto/v1"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+type testPrefs struct {
+	Plan   string `json:"plan"`
+	Limits struct {
+		API int `json:"api"`
+	} `json:"limits"`
+}
+
+func TestDecodeMetadataSources(t *testing.T) {
+	metadata := map[string]any{"plan": "pro", "limits": map[string]any{"api": 100}}
+	st, err := structpb.NewStruct(metadata)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decode := map[string]func() (testPrefs, error){
+		"pb.User":   func() (testPrefs, error) { return DecodeMetadata[testPrefs](&pb.User{Metadata: st}) },
+		"User":      func() (testPrefs, error) { return DecodeMetadata[testPrefs](&User{Metadata: metadata}) },
+		"map":       func() (testPrefs, error) { return DecodeMetadata[testPrefs](metadata) },
+		"pb.Tenant": func() (testPrefs, error) { return DecodeSettings[testPrefs](&pb.Tenant{Settings: st}) },
+		"Tenant":    func() (testPrefs, error) { return DecodeSettings[testPrefs](&Tenant{Settings: metadata}) },
+	}
+	for name, fn := range decode {
+		got, err := fn()
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if got.Plan != "pro" || got.Limits.API != 100 {
+			t.Errorf("%s: got %+v", name, got)
+		}
+	}
+
+	if got, err := DecodeMetadata[testPrefs]((*User)(nil)); err != nil || got != (testPrefs{}) {
+		t.Errorf("nil user: got %+v, %v", got, err)
+	}
+	if _, err := DecodeMetadata[testPrefs](map[string]any{"plan": make(chan int)}); !errors.Is(err, ErrSchemaMismatch) {
+		t.Errorf("unrepresentable metadata: got %v, want ErrSchemaMismatch", err)
+	}
+}
+
+func TestSchemaValidate(t *testing.T) {
+	s, err := CompileSchema([]byte(`{
+		"type": "object",
+		"properties": {"limits": {"type": "object", "properties": {"api": {"type": "integer"}}}}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Validate(nil); err != nil {
+		t.Errorf("nil document: %v", err)
+	}
+	err = s.Validate(map[string]any{"limits": map[string]any{"api": "many"}})
+	var se *SchemaError
+	if !errors.As(err, &se) {
+		t.Fatalf("got %v, want a SchemaError", err)
+	}
+	if se.Field != "limits.api" || se.Reason != "got string, want integer" {
+		t.Errorf("got field %q reason %q", se.Field, se.Reason)
+	}
+}
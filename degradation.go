@@ -0,0 +1,54 @@
+package identity
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// DegradationMode tells how a request was served
+// while the Identity Service was unavailable.
+type DegradationMode string
+
+const (
+	// DegradedStaleCache means the session was served from
+	// the cache past its TTL, within the stale grace window.
+	DegradedStaleCache DegradationMode = "stale-cache"
+	// DegradedFailOpen means the request was let through unauthenticated.
+	DegradedFailOpen DegradationMode = "fail-open"
+)
+
+// DegradationPolicy configures how the Client behaves when the Identity
+// Service is unavailable. The zero value fails closed: every validation
+// that cannot reach the service fails.
+type DegradationPolicy struct {
+	// StaleGrace serves the cached sessions up to StaleGrace past their
+	// cache TTL, but never past the session expiry. Requires WithSessionCache.
+	StaleGrace time.Duration
+}
+
+// WithDegradation sets the DegradationPolicy of the Client.
+func WithDegradation(p DegradationPolicy) Option {
+	return func(o *options) {
+		o.degradation = p
+	}
+}
+
+// IsUnavailable reports whether the error means the Identity Service
+// could not be reached, as opposed to a rejected session.
+func IsUnavailable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return true
+	}
+	return false
+}
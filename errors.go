@@ -2,9 +2,11 @@ package identity
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/kodeart/go-problem/v2"
 	"google.golang.org/genproto/googleapis/rpc/errdetails"
@@ -12,6 +14,32 @@ import (
 	"google.golang.org/grpc/status"
 )
 
+// ErrInvalidSession is matched by every InvalidSessionError,
+// e.g. errors.Is(err, identity.ErrInvalidSession).
+var ErrInvalidSession = errors.New("identity: invalid session")
+
+// InvalidSessionError is returned when the Identity Service
+// reports the token as not valid.
+type InvalidSessionError struct {
+	Reason string
+}
+
+func (e *InvalidSessionError) Error() string {
+	if e.Reason == "" {
+		return ErrInvalidSession.Error()
+	}
+	return ErrInvalidSession.Error() + ": " + e.Reason
+}
+
+func (e *InvalidSessionError) Is(target error) bool {
+	return target == ErrInvalidSession
+}
+
+// GRPCStatus lets AsProblem and status.Convert treat the error as Unauthenticated.
+func (e *InvalidSessionError) GRPCStatus() *status.Status {
+	return status.New(codes.Unauthenticated, e.Error())
+}
+
 // AsProblem converts a gRPC error to a problem.Problem
 // and attaches request-specific information if any.
 func AsProblem(r *http.Request, err error) *problem.Problem {
@@ -23,7 +51,7 @@ func AsProblem(r *http.Request, err error) *problem.Problem {
 	}
 	p := &problem.Problem{
 		Status:   CodeToHttpStatus(st.Code()),
-		Instance: fmt.Sprintf(" %s://%s%s", scheme, r.Host, r.RequestURI),
+		Instance: fmt.Sprintf("%s://%s%s", scheme, r.Host, r.RequestURI),
 		Detail:   rawMsg,
 		Title:    st.Code().String(),
 		Type:     getType(st.Code(), scheme, r.Host),
@@ -45,6 +73,9 @@ func AsProblem(r *http.Request, err error) *problem.Problem {
 			}
 		}
 	}
+	if d, ok := RetryAfter(err); ok {
+		p.WithExtension("retry_after", int(d.Round(time.Second)/time.Second))
+	}
 	// Extract SDK details (BadRequest / FieldViolations)
 	for _, detail := range st.Details() {
 		if t, ok := detail.(*errdetails.BadRequest); ok {
@@ -57,6 +88,16 @@ func AsProblem(r *http.Request, err error) *problem.Problem {
 	return p
 }
 
+// RetryAfter returns how long the caller should wait
+// before retrying, if the error tells it.
+func RetryAfter(err error) (time.Duration, bool) {
+	var coe *CircuitOpenError
+	if errors.As(err, &coe) {
+		return max(coe.RetryAfter, time.Second), true
+	}
+	return 0, false
+}
+
 // CodeToHttpStatus converts gRPC error code
 // to its corresponding HTTP status code.
 func CodeToHttpStatus(code codes.Code) int {
@@ -93,6 +134,8 @@ func getType(code codes.Code, scheme string, host string) string {
 	switch code {
 	case codes.Unauthenticated:
 		t = "invalid-session"
+	case codes.PermissionDenied:
+		t = "forbidden"
 	case codes.InvalidArgument:
 		t = "validation-failed"
 	case codes.DeadlineExceeded:
@@ -0,0 +1,183 @@
+package identity
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+
+	pb "github.com/kodeart/identity-sdk-go/proto/v1"
+)
+
+// KeySource provides the JSON Web Key Set (RFC 7517)
+// used by the Verifier to check the token signatures.
+type KeySource interface {
+	FetchJWKS(ctx context.Context) ([]byte, error)
+}
+
+// FetchJWKS retrieves the signing keys of the Identity Service,
+// so the Client itself can be used as the KeySource of a Verifier.
+func (c *Client) FetchJWKS(ctx context.Context) ([]byte, error) {
+	resp, err := c.grpcsvc.GetJWKS(ctx, &pb.GetJWKSRequest{})
+	if err != nil {
+		return nil, err
+	}
+	return resp.GetJwks(), nil
+}
+
+// JWKSFile reads the key set from a file on every refresh.
+func JWKSFile(path string) KeySource {
+	return keySourceFunc(func(context.Context) ([]byte, error) {
+		return os.ReadFile(path)
+	})
+}
+
+// JWKSURL downloads the key set from the URL on every refresh,
+// e.g. "https://identity.example.com/.well-known/jwks.json".
+// A nil httpClient uses http.DefaultClient.
+func JWKSURL(url string, httpClient *http.Client) KeySource {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return keySourceFunc(func(ctx context.Context) ([]byte, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "application/json")
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("identity: fetching %s: unexpected status %s", url, resp.Status)
+		}
+		return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	})
+}
+
+type keySourceFunc func(ctx context.Context) ([]byte, error)
+
+func (fn keySourceFunc) FetchJWKS(ctx context.Context) ([]byte, error) {
+	return fn(ctx)
+}
+
+// jwk is a single JSON Web Key, only the public members are read.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// publicKey is a parsed verification key.
+type publicKey struct {
+	kid string
+	alg string
+	key crypto.PublicKey
+}
+
+// parseJWKS parses the key set, skipping keys that are not
+// meant for signatures or use an unsupported key type.
+func parseJWKS(data []byte) ([]publicKey, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("identity: invalid JWKS: %w", err)
+	}
+	keys := make([]publicKey, 0, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		pub, err := k.publicKey()
+		if err != nil {
+			continue
+		}
+		keys = append(keys, publicKey{kid: k.Kid, alg: k.Alg, key: pub})
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("identity: JWKS contains no usable signing key")
+	}
+	return keys, nil
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, errors.New("identity: invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("identity: unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		pub := &ecdsa.PublicKey{Curve: curve, X: x, Y: y}
+		if _, err := pub.ECDH(); err != nil {
+			return nil, fmt.Errorf("identity: invalid EC key: %w", err)
+		}
+		return pub, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("identity: unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("identity: invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("identity: unsupported key type %q", k.Kty)
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, errors.New("identity: invalid key parameter")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,132 @@
+package identity
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// WithLogger sets the logger of the Client, defaults to the global zerolog logger.
+//
+// Tokens, passwords and refresh tokens are never written as is, whatever the level.
+func WithLogger(l *zerolog.Logger) Option {
+	return func(o *options) {
+		if l != nil {
+			o.logger = *l
+		}
+	}
+}
+
+// WithSlogHandler logs through the slog handler instead of zerolog.
+func WithSlogHandler(h slog.Handler) Option {
+	return WithLogger(NewSlogLogger(h))
+}
+
+// NewSlogLogger returns a zerolog logger writing its events to the slog handler,
+// e.g. to pass a slog handler as VerifierConfig.Logger.
+func NewSlogLogger(h slog.Handler) *zerolog.Logger {
+	l := zerolog.New(slogWriter{h}).With().Timestamp().Logger()
+	for _, lvl := range []zerolog.Level{zerolog.TraceLevel, zerolog.DebugLevel, zerolog.InfoLevel, zerolog.WarnLevel} {
+		if h.Enabled(context.Background(), slogLevel(lvl)) {
+			l = l.Level(lvl)
+			return &l
+		}
+	}
+	l = l.Level(zerolog.ErrorLevel)
+	return &l
+}
+
+// redactToken replaces a token by its fingerprint, so log lines
+// about the same token can be correlated without disclosing it.
+func redactToken(token string) string {
+	if token == "" {
+		return ""
+	}
+	key := keyOf(token)
+	return "sha256:" + hex.EncodeToString(key[:4])
+}
+
+// redact hides a secret that must not even be fingerprinted, e.g. a password.
+func redact(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return "[REDACTED]"
+}
+
+// slogWriter turns the JSON events of zerolog into slog records.
+type slogWriter struct {
+	h slog.Handler
+}
+
+func (w slogWriter) Write(p []byte) (int, error) {
+	return w.WriteLevel(zerolog.NoLevel, p)
+}
+
+func (w slogWriter) WriteLevel(l zerolog.Level, p []byte) (int, error) {
+	ctx := context.Background()
+	lvl := slogLevel(l)
+	if !w.h.Enabled(ctx, lvl) {
+		return len(p), nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(p))
+	dec.UseNumber()
+	if _, err := dec.Token(); err != nil {
+		return 0, err
+	}
+	var (
+		msg   string
+		ts    = time.Now()
+		attrs []slog.Attr
+	)
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return 0, err
+		}
+		key, _ := tok.(string)
+		var v any
+		if err := dec.Decode(&v); err != nil {
+			return 0, err
+		}
+		switch key {
+		case zerolog.MessageFieldName:
+			msg, _ = v.(string)
+		case zerolog.LevelFieldName:
+		case zerolog.TimestampFieldName:
+			if s, ok := v.(string); ok {
+				if t, err := time.Parse(zerolog.TimeFieldFormat, s); err == nil {
+					ts = t
+				}
+			}
+		default:
+			attrs = append(attrs, slog.Any(key, v))
+		}
+	}
+	r := slog.NewRecord(ts, lvl, msg, 0)
+	r.AddAttrs(attrs...)
+	if err := w.h.Handle(ctx, r); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func slogLevel(l zerolog.Level) slog.Level {
+	switch l {
+	case zerolog.TraceLevel:
+		return slog.LevelDebug - 4
+	case zerolog.DebugLevel:
+		return slog.LevelDebug
+	case zerolog.WarnLevel:
+		return slog.LevelWarn
+	case zerolog.ErrorLevel, zerolog.FatalLevel, zerolog.PanicLevel:
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
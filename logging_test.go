@@ -0,0 +1,143 @@
+package identity
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	pb "github.com/kodeart/identity-sdk-go/proto/v1"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	secretToken        = "eyJhbGciOiJSUzI1NiJ9.secret-access-token"
+	secretPassword     = "correct horse battery staple"
+	secretRefreshToken = "secret-refresh-token"
+)
+
+// lockedBuffer collects the log output, written by the background
+// goroutines of the Client too.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestLoggerRedactsSecrets(t *testing.T) {
+	tests := []struct {
+		name   string
+		logger func(*lockedBuffer) Option
+	}{
+		{"zerolog", func(buf *lockedBuffer) Option {
+			l := zerolog.New(buf).Level(zerolog.TraceLevel)
+			return WithLogger(&l)
+		}},
+		{"slog", func(buf *lockedBuffer) Option {
+			return WithSlogHandler(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug - 4}))
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf lockedBuffer
+			exerciseSecrets(t, tt.logger(&buf))
+
+			out := buf.String()
+			for _, secret := range []string{secretToken, secretPassword, secretRefreshToken} {
+				if strings.Contains(out, secret) {
+					t.Errorf("log output contains the secret %q:\n%s", secret, out)
+				}
+			}
+			for _, msg := range []string{
+				"verify user token",
+				"authenticate with credentials",
+				"refresh session",
+				"serving the session from the stale cache",
+				"failed to refresh the session",
+			} {
+				if !strings.Contains(out, msg) {
+					t.Errorf("log output misses %q, the path was not exercised:\n%s", msg, out)
+				}
+			}
+		})
+	}
+}
+
+// exerciseSecrets runs every logging path handling a secret.
+func exerciseSecrets(t *testing.T, logger Option) {
+	t.Helper()
+	var down atomic.Bool
+	unavailable := status.Error(codes.Unavailable, "down")
+	addr := startServer(t, &fakeIdentityService{
+		authenticate: func(context.Context, *pb.AuthenticateRequest) (*pb.AuthenticateResponse, error) {
+			return nil, status.Error(codes.Unauthenticated, "bad credentials")
+		},
+		validateSession: func(context.Context, *pb.ValidateSessionRequest) (*pb.ValidateSessionResponse, error) {
+			if down.Load() {
+				return nil, unavailable
+			}
+			return &pb.ValidateSessionResponse{
+				Valid:     true,
+				User:      &pb.User{Id: "u1", TenantId: "t1"},
+				ExpiresAt: timestamppb.New(time.Now().Add(time.Hour)),
+			}, nil
+		},
+		refreshSession: func(context.Context, *pb.RefreshSessionRequest) (*pb.AuthenticateResponse, error) {
+			return nil, unavailable
+		},
+	})
+	c, err := NewClient(addr, logger, WithoutRetry(),
+		WithSessionCache(CacheConfig{TTL: time.Millisecond}),
+		WithDegradation(DegradationPolicy{StaleGrace: time.Hour}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	ctx := context.Background()
+
+	if _, err := c.ValidateSession(ctx, secretToken); err != nil {
+		t.Fatal(err)
+	}
+	down.Store(true)
+	time.Sleep(5 * time.Millisecond)
+	if res, err := c.ValidateSession(ctx, secretToken); err != nil || !res.Degraded {
+		t.Fatalf("want a degraded session, got %v, %v", res, err)
+	}
+	if _, err := c.AuthenticateWithCredentials(ctx, "acme", "ada@example.com", secretPassword); err == nil {
+		t.Fatal("want an authentication error")
+	}
+	if _, err := c.RefreshSession(ctx, secretRefreshToken); err == nil {
+		t.Fatal("want a refresh error")
+	}
+
+	ts, err := NewTokenSource(c, &pb.AuthenticateResponse{
+		AccessToken:  secretToken,
+		RefreshToken: secretRefreshToken,
+		ExpiresAt:    timestamppb.New(time.Now().Add(200 * time.Millisecond)),
+	}, WithRefreshJitter(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(120 * time.Millisecond)
+	if token, err := ts.Token(ctx); err != nil || token != secretToken {
+		t.Fatalf("want the current token after a failed refresh, got %q, %v", token, err)
+	}
+}
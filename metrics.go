@@ -0,0 +1,103 @@
+package identity
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Metrics receives the measurements of the Client and of the middleware.
+// Implementations must be safe for concurrent use, see the prommetrics
+// package for a Prometheus implementation.
+type Metrics interface {
+	// ObserveRPC is called once per finished call of the Identity Service.
+	ObserveRPC(method string, code codes.Code, duration time.Duration)
+	// ObserveCache is called on every session cache lookup.
+	ObserveCache(result CacheResult)
+	// ObserveAuth is called with the outcome of every authenticated request.
+	ObserveAuth(outcome AuthOutcome)
+}
+
+// CacheResult is the result of a session cache lookup.
+type CacheResult string
+
+const (
+	CacheHit  CacheResult = "hit"
+	CacheMiss CacheResult = "miss"
+	// CacheStale is a session served past its TTL while the Identity Service is unavailable.
+	CacheStale CacheResult = "stale"
+)
+
+// AuthOutcome is how the middleware handled a request.
+type AuthOutcome string
+
+const (
+	AuthMissing     AuthOutcome = "missing"
+	AuthInvalid     AuthOutcome = "invalid"
+	AuthAuthorized  AuthOutcome = "authorized"
+	AuthDegraded    AuthOutcome = "degraded"
+	AuthUnavailable AuthOutcome = "unavailable"
+)
+
+// WithMetrics reports the RPCs and the session cache lookups of the Client.
+func WithMetrics(m Metrics) Option {
+	return func(o *options) {
+		if m != nil {
+			o.metrics = m
+		}
+	}
+}
+
+type nopMetrics struct{}
+
+func (nopMetrics) ObserveRPC(string, codes.Code, time.Duration) {}
+func (nopMetrics) ObserveCache(CacheResult)                     {}
+func (nopMetrics) ObserveAuth(AuthOutcome)                      {}
+
+// rpcMetrics times the calls of a ClientConn.
+type rpcMetrics struct {
+	m Metrics
+}
+
+func (rm rpcMetrics) unaryInterceptor(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	start := time.Now()
+	err := invoker(ctx, method, req, reply, cc, opts...)
+	rm.m.ObserveRPC(method, status.Code(err), time.Since(start))
+	return err
+}
+
+func (rm rpcMetrics) streamInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	start := time.Now()
+	cs, err := streamer(ctx, desc, cc, method, opts...)
+	if err != nil {
+		rm.m.ObserveRPC(method, status.Code(err), time.Since(start))
+		return nil, err
+	}
+	return &observedStream{ClientStream: cs, observe: func(err error) {
+		rm.m.ObserveRPC(method, status.Code(err), time.Since(start))
+	}}, nil
+}
+
+// observedStream reports the stream once it ends.
+type observedStream struct {
+	grpc.ClientStream
+	observe func(error)
+	once    sync.Once
+}
+
+func (s *observedStream) RecvMsg(m any) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err != nil {
+		if err == io.EOF {
+			s.once.Do(func() { s.observe(nil) })
+		} else {
+			s.once.Do(func() { s.observe(err) })
+		}
+	}
+	return err
+}
@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/kodeart/go-problem/v2"
+	"github.com/kodeart/identity-sdk-go"
+)
+
+// RequireRole lets through the users granted the role.
+// It must be mounted after IdentityAuth.
+func RequireRole(role string) func(http.Handler) http.Handler {
+	return RequireAnyRole(role)
+}
+
+// RequireAnyRole lets through the users granted at least one of the roles.
+func RequireAnyRole(roles ...string) func(http.Handler) http.Handler {
+	return authorize("Missing required role", "required_roles", roles, func(p *identity.Principal) bool {
+		return slices.ContainsFunc(roles, p.HasRole)
+	})
+}
+
+// RequirePermission lets through the users granted the permission.
+func RequirePermission(permission string) func(http.Handler) http.Handler {
+	return RequireAnyPermission(permission)
+}
+
+// RequireAnyPermission lets through the users granted at least one of the permissions.
+func RequireAnyPermission(permissions ...string) func(http.Handler) http.Handler {
+	return authorize("Missing required permission", "required_permissions", permissions, func(p *identity.Principal) bool {
+		return slices.ContainsFunc(permissions, p.HasPermission)
+	})
+}
+
+// RequireAllPermissions lets through the users granted every one of the permissions.
+func RequireAllPermissions(permissions ...string) func(http.Handler) http.Handler {
+	return authorize("Missing required permission", "required_permissions", permissions, func(p *identity.Principal) bool {
+		for _, perm := range permissions {
+			if !p.HasPermission(perm) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// RequireTenant lets through the users belonging to the tenant with the slug.
+func RequireTenant(slug string) func(http.Handler) http.Handler {
+	return authorize("Tenant not allowed", "required_tenant", slug, func(p *identity.Principal) bool {
+		return p.TenantSlug == slug
+	})
+}
+
+// authorize responds with 401 to unauthenticated requests
+// and with 403 to the principals not allowed by the check.
+func authorize(detail, extension string, required any, allowed func(*identity.Principal) bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			p, ok := identity.PrincipalFromContext(r.Context())
+			if !ok {
+				problem.New().
+					WithDetail("Missing identity").
+					WithTitle("Identity Not Authorized").
+					WithStatus(http.StatusUnauthorized).
+					WithInstance(r.URL.String()).
+					JSON(w)
+
+				return
+			}
+			if !allowed(p) {
+				problem.New().
+					WithDetail(detail).
+					WithTitle("Identity Forbidden").
+					WithStatus(http.StatusForbidden).
+					WithInstance(r.URL.String()).
+					WithExtension(extension, required).
+					JSON(w)
+
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
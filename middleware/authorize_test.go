@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kodeart/identity-sdk-go"
+	pb "github.com/kodeart/identity-sdk-go/proto/v1"
+)
+
+func TestAuthorize(t *testing.T) {
+	principal := &identity.Principal{
+		User: &pb.User{
+			Id:          "u1",
+			Roles:       []string{"editor"},
+			Permissions: []string{"docs:read", "docs:edit"},
+		},
+		TenantSlug: "acme",
+	}
+	tests := []struct {
+		name      string
+		mw        func(http.Handler) http.Handler
+		principal *identity.Principal
+		status    int
+		extension string
+	}{
+		{"role", RequireRole("editor"), principal, http.StatusNoContent, ""},
+		{"missing role", RequireRole("admin"), principal, http.StatusForbidden, "required_roles"},
+		{"any role", RequireAnyRole("admin", "editor"), principal, http.StatusNoContent, ""},
+		{"permission", RequirePermission("docs:edit"), principal, http.StatusNoContent, ""},
+		{"missing permission", RequirePermission("docs:delete"), principal, http.StatusForbidden, "required_permissions"},
+		{"any permission", RequireAnyPermission("docs:delete", "docs:read"), principal, http.StatusNoContent, ""},
+		{"all permissions", RequireAllPermissions("docs:read", "docs:edit"), principal, http.StatusNoContent, ""},
+		{"not all permissions", RequireAllPermissions("docs:read", "docs:delete"), principal, http.StatusForbidden, "required_permissions"},
+		{"tenant", RequireTenant("acme"), principal, http.StatusNoContent, ""},
+		{"other tenant", RequireTenant("globex"), principal, http.StatusForbidden, "required_tenant"},
+		{"unauthenticated role", RequireRole("editor"), nil, http.StatusUnauthorized, ""},
+		{"unauthenticated tenant", RequireTenant("acme"), nil, http.StatusUnauthorized, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/docs", nil)
+			if tt.principal != nil {
+				r = r.WithContext(identity.WithPrincipal(r.Context(), tt.principal))
+			}
+			var reached bool
+			w := serve(tt.mw, okHandler(&reached), r)
+			if w.Code != tt.status || reached != (tt.status == http.StatusNoContent) {
+				t.Fatalf("got %d, reached=%t, want %d", w.Code, reached, tt.status)
+			}
+			if tt.extension == "" {
+				return
+			}
+			var body map[string]any
+			if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := body[tt.extension]; !ok {
+				t.Errorf("problem misses %q: %v", tt.extension, body)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/kodeart/identity-sdk-go"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrMissingToken is passed to the ErrorHandler when
+// none of the extractors found a session token.
+var ErrMissingToken = status.Error(codes.Unauthenticated, "Missing session token")
+
+// ErrorHandler writes the response of a request that failed the authentication.
+type ErrorHandler func(w http.ResponseWriter, r *http.Request, err error)
+
+// WithErrorHandler replaces the DefaultErrorHandler.
+func WithErrorHandler(h ErrorHandler) Option {
+	return func(c *config) {
+		c.errorHandler = h
+	}
+}
+
+// DefaultErrorHandler writes the error as a problem document through
+// identity.AsProblem, so an invalid token is a 401 and an unavailable
+// Identity Service a 503, with a Retry-After header when known.
+// The error message itself is never written, since it may carry
+// internal details of the Identity Service.
+func DefaultErrorHandler(w http.ResponseWriter, r *http.Request, err error) {
+	p := identity.AsProblem(r, publicError(err))
+	if d, ok := identity.RetryAfter(err); ok {
+		seconds := int(d.Round(time.Second) / time.Second)
+		w.Header().Set("Retry-After", strconv.Itoa(seconds))
+		p.WithExtension("retry_after", seconds)
+	}
+	p.JSON(w)
+}
+
+// publicError keeps the status code of the error, replacing its message.
+func publicError(err error) error {
+	if errors.Is(err, ErrMissingToken) {
+		return err
+	}
+	// A token the Verifier cannot decide on, e.g. not a JWT, is not
+	// a valid session either when no fallback validator is configured.
+	if errors.Is(err, identity.ErrInvalidSession) || errors.Is(err, identity.ErrUnverifiable) {
+		return status.Error(codes.Unauthenticated, "Invalid Token")
+	}
+	code := status.Code(err)
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		code = status.FromContextError(err).Code()
+	}
+	switch code {
+	case codes.Unauthenticated:
+		return status.Error(code, "Invalid Token")
+	case codes.PermissionDenied:
+		return status.Error(code, "Access denied")
+	case codes.Unavailable, codes.ResourceExhausted:
+		return status.Error(code, "The identity service is unavailable")
+	case codes.DeadlineExceeded, codes.Canceled:
+		return status.Error(codes.DeadlineExceeded, "The identity service did not respond in time")
+	default:
+		return status.Error(codes.Internal, "The session could not be validated")
+	}
+}
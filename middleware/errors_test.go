@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kodeart/identity-sdk-go"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestDefaultErrorHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		status     int
+		detail     string
+		retryAfter string
+	}{
+		{"missing token", ErrMissingToken, http.StatusUnauthorized, "Missing session token", ""},
+		{"invalid session", &identity.InvalidSessionError{Reason: "session revoked"}, http.StatusUnauthorized, "Invalid Token", ""},
+		{"unverifiable", fmt.Errorf("%w: not a JWT", identity.ErrUnverifiable), http.StatusUnauthorized, "Invalid Token", ""},
+		{"unauthenticated", status.Error(codes.Unauthenticated, "token abc expired"), http.StatusUnauthorized, "Invalid Token", ""},
+		{"permission denied", status.Error(codes.PermissionDenied, "user u1 suspended"), http.StatusForbidden, "Access denied", ""},
+		{"unavailable", status.Error(codes.Unavailable, "dial tcp 10.0.0.7:443"), http.StatusServiceUnavailable, "The identity service is unavailable", ""},
+		{"circuit open", &identity.CircuitOpenError{Method: "/identity.v1.IdentityService/ValidateSession", RetryAfter: 2400 * time.Millisecond},
+			http.StatusServiceUnavailable, "The identity service is unavailable", "2"},
+		{"deadline", context.DeadlineExceeded, http.StatusGatewayTimeout, "The identity service did not respond in time", ""},
+		{"internal", errors.New("pq: password authentication failed"), http.StatusInternalServerError, "The session could not be validated", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			DefaultErrorHandler(w, httptest.NewRequest(http.MethodGet, "/", nil), tt.err)
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d", w.Code, tt.status)
+			}
+			if got := w.Header().Get("Retry-After"); got != tt.retryAfter {
+				t.Errorf("Retry-After = %q, want %q", got, tt.retryAfter)
+			}
+			var body map[string]any
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("%v: %s", err, w.Body)
+			}
+			if body["detail"] != tt.detail {
+				t.Errorf("detail = %v, want %q", body["detail"], tt.detail)
+			}
+			if tt.retryAfter != "" && fmt.Sprint(body["retry_after"]) != tt.retryAfter {
+				t.Errorf("retry_after = %v, want %s", body["retry_after"], tt.retryAfter)
+			}
+			if strings.Contains(w.Body.String(), "ValidateSession") || strings.Contains(w.Body.String(), "10.0.0.7") {
+				t.Errorf("the problem leaks the error: %s", w.Body)
+			}
+		})
+	}
+}
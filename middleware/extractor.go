@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+)
+
+// TokenExtractor returns the session token carried by the request, if any.
+// Any function with this signature can be used as a custom extractor.
+type TokenExtractor func(r *http.Request) (string, bool)
+
+// FromHeader reads the token from the named header. When the scheme is
+// not empty, e.g. "Bearer", the header value must start with it; the
+// scheme is matched case-insensitively, as required by RFC 6750.
+func FromHeader(name, scheme string) TokenExtractor {
+	return func(r *http.Request) (string, bool) {
+		return tokenWithScheme(r.Header.Values(name), scheme)
+	}
+}
+
+// FromBearer reads the token from the "Authorization: Bearer" header.
+func FromBearer() TokenExtractor {
+	return FromHeader("Authorization", "Bearer")
+}
+
+// FromCookie reads the token from the named cookie,
+// e.g. the HttpOnly session cookie of a browser app.
+func FromCookie(name string) TokenExtractor {
+	return func(r *http.Request) (string, bool) {
+		c, err := r.Cookie(name)
+		if err != nil || c.Value == "" {
+			return "", false
+		}
+		return c.Value, true
+	}
+}
+
+// FromQuery reads the token from the named query parameter. It is meant for
+// WebSocket upgrades, where browsers cannot set headers; tokens in URLs
+// end up in access logs, so prefer the other extractors when possible.
+func FromQuery(param string) TokenExtractor {
+	return func(r *http.Request) (string, bool) {
+		token := r.URL.Query().Get(param)
+		return token, token != ""
+	}
+}
+
+// tokenWithScheme returns the token of the first value using the scheme.
+func tokenWithScheme(values []string, scheme string) (string, bool) {
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if scheme != "" {
+			s, token, ok := strings.Cut(v, " ")
+			if !ok || !strings.EqualFold(s, scheme) {
+				continue
+			}
+			v = strings.TrimSpace(token)
+		}
+		if v != "" {
+			return v, true
+		}
+	}
+	return "", false
+}
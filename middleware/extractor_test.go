@@ -0,0 +1,112 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kodeart/identity-sdk-go"
+)
+
+func TestFromHeader(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []string
+		scheme string
+		token  string
+		ok     bool
+	}{
+		{"bearer", []string{"Bearer abc"}, "Bearer", "abc", true},
+		{"lowercase scheme", []string{"bearer abc"}, "Bearer", "abc", true},
+		{"uppercase scheme", []string{"BEARER abc"}, "Bearer", "abc", true},
+		{"extra spaces", []string{"  Bearer   abc  "}, "Bearer", "abc", true},
+		{"other scheme", []string{"Basic dXNlcjpwYXNz"}, "Bearer", "", false},
+		{"scheme only", []string{"Bearer"}, "Bearer", "", false},
+		{"empty token", []string{"Bearer   "}, "Bearer", "", false},
+		{"scheme prefix of a word", []string{"Bearerabc"}, "Bearer", "", false},
+		{"first value with the scheme", []string{"Basic x", "Bearer abc"}, "Bearer", "abc", true},
+		{"no scheme", []string{" abc "}, "", "abc", true},
+		{"missing header", nil, "Bearer", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			for _, v := range tt.values {
+				r.Header.Add("X-Token", v)
+			}
+			token, ok := FromHeader("X-Token", tt.scheme)(r)
+			if token != tt.token || ok != tt.ok {
+				t.Errorf("got %q, %t, want %q, %t", token, ok, tt.token, tt.ok)
+			}
+		})
+	}
+}
+
+func TestFromCookieAndQuery(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/ws?access_token=from-query&empty=", nil)
+	r.AddCookie(&http.Cookie{Name: "session", Value: "from-cookie"})
+	r.AddCookie(&http.Cookie{Name: "blank", Value: ""})
+
+	if token, ok := FromCookie("session")(r); !ok || token != "from-cookie" {
+		t.Errorf("FromCookie: got %q, %t", token, ok)
+	}
+	for _, name := range []string{"blank", "missing"} {
+		if _, ok := FromCookie(name)(r); ok {
+			t.Errorf("FromCookie(%q) reports a token", name)
+		}
+	}
+	if token, ok := FromQuery("access_token")(r); !ok || token != "from-query" {
+		t.Errorf("FromQuery: got %q, %t", token, ok)
+	}
+	for _, name := range []string{"empty", "missing"} {
+		if _, ok := FromQuery(name)(r); ok {
+			t.Errorf("FromQuery(%q) reports a token", name)
+		}
+	}
+}
+
+func TestTokenExtractorChain(t *testing.T) {
+	var validated []string
+	auth := IdentityAuthWithOptions(validatorFunc(func(_ context.Context, token string) (*identity.SessionResult, error) {
+		validated = append(validated, token)
+		return &identity.SessionResult{Valid: true}, nil
+	}), WithTokenExtractors(FromBearer(), FromCookie("session"), FromQuery("access_token")))
+
+	tests := []struct {
+		name  string
+		setup func(r *http.Request)
+		token string
+	}{
+		{"header first", func(r *http.Request) {
+			r.Header.Set("Authorization", "bearer from-header")
+			r.AddCookie(&http.Cookie{Name: "session", Value: "from-cookie"})
+		}, "from-header"},
+		{"cookie without header", func(r *http.Request) {
+			r.AddCookie(&http.Cookie{Name: "session", Value: "from-cookie"})
+		}, "from-cookie"},
+		{"other scheme falls through", func(r *http.Request) {
+			r.Header.Set("Authorization", "Basic dXNlcjpwYXNz")
+			r.URL.RawQuery = "access_token=from-query"
+		}, "from-query"},
+		{"nothing", func(*http.Request) {}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validated = nil
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			tt.setup(r)
+			var reached bool
+			w := serve(auth, okHandler(&reached), r)
+			if tt.token == "" {
+				if w.Code != http.StatusUnauthorized || len(validated) != 0 {
+					t.Fatalf("got %d, validated %v, want 401 without validation", w.Code, validated)
+				}
+				return
+			}
+			if !reached || len(validated) != 1 || validated[0] != tt.token {
+				t.Fatalf("got %d, validated %v, want %q", w.Code, validated, tt.token)
+			}
+		})
+	}
+}
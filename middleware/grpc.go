@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+
+	"github.com/kodeart/identity-sdk-go"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// InterceptorOption configures the gRPC server interceptors.
+type InterceptorOption func(*interceptorConfig)
+
+type interceptorConfig struct {
+	exempt map[string]bool
+}
+
+// WithExemptMethods lets the listed full method names,
+// e.g. "/grpc.health.v1.Health/Check", through without authentication.
+func WithExemptMethods(methods ...string) InterceptorOption {
+	return func(c *interceptorConfig) {
+		for _, m := range methods {
+			c.exempt[m] = true
+		}
+	}
+}
+
+func newInterceptorConfig(opts []InterceptorOption) *interceptorConfig {
+	c := &interceptorConfig{exempt: make(map[string]bool)}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// UnaryServerInterceptor authenticates incoming unary calls with the
+// bearer token of the "authorization" metadata and stores the
+// identity.Principal in the handler context.
+//
+// Missing or invalid tokens are rejected with codes.Unauthenticated,
+// an unreachable Identity Service with codes.Unavailable.
+func UnaryServerInterceptor(validator identity.SessionValidator, opts ...InterceptorOption) grpc.UnaryServerInterceptor {
+	cfg := newInterceptorConfig(opts)
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if cfg.exempt[info.FullMethod] {
+			return handler(ctx, req)
+		}
+		ctx, err := authenticate(ctx, validator)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor is the streaming counterpart of UnaryServerInterceptor.
+func StreamServerInterceptor(validator identity.SessionValidator, opts ...InterceptorOption) grpc.StreamServerInterceptor {
+	cfg := newInterceptorConfig(opts)
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if cfg.exempt[info.FullMethod] {
+			return handler(srv, ss)
+		}
+		ctx, err := authenticate(ss.Context(), validator)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &authenticatedStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// authenticatedStream overrides the context of the wrapped stream.
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+// authenticate validates the bearer token of the incoming metadata
+// and returns the context carrying the principal.
+func authenticate(ctx context.Context, validator identity.SessionValidator) (context.Context, error) {
+	token, ok := tokenWithScheme(metadata.ValueFromIncomingContext(ctx, "authorization"), "Bearer")
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "missing bearer token in authorization metadata")
+	}
+	session, err := validator.ValidateSession(ctx, token)
+	if err != nil {
+		return nil, rpcError(err)
+	}
+	return identity.WithPrincipal(ctx, identity.NewPrincipal(token, session)), nil
+}
+
+// rpcError hides the validation details from the caller.
+func rpcError(err error) error {
+	if errors.Is(err, identity.ErrInvalidSession) {
+		return status.Error(codes.Unauthenticated, "invalid session")
+	}
+	if identity.IsUnavailable(err) {
+		return status.Error(codes.Unavailable, "identity service unavailable")
+	}
+	return status.Error(codes.Unauthenticated, "session could not be validated")
+}
@@ -0,0 +1,133 @@
+package middleware
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/kodeart/identity-sdk-go"
+	pb "github.com/kodeart/identity-sdk-go/proto/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// principalService answers with the id of the authenticated user.
+type principalService struct {
+	pb.UnimplementedIdentityServiceServer
+}
+
+func (principalService) GetUser(ctx context.Context, _ *pb.GetUserRequest) (*pb.User, error) {
+	p, ok := identity.PrincipalFromContext(ctx)
+	if !ok {
+		return &pb.User{Id: "anonymous"}, nil
+	}
+	return &pb.User{Id: p.User.GetId()}, nil
+}
+
+func (principalService) WatchRevocations(_ *pb.WatchRevocationsRequest, stream grpc.ServerStreamingServer[pb.RevocationEvent]) error {
+	p, ok := identity.PrincipalFromContext(stream.Context())
+	if !ok {
+		return status.Error(codes.Internal, "no principal in the stream context")
+	}
+	return stream.Send(&pb.RevocationEvent{Target: &pb.RevocationEvent_UserId{UserId: p.User.GetId()}})
+}
+
+func newInterceptedClient(t *testing.T, validator identity.SessionValidator, opts ...InterceptorOption) pb.IdentityServiceClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	s := grpc.NewServer(
+		grpc.UnaryInterceptor(UnaryServerInterceptor(validator, opts...)),
+		grpc.StreamInterceptor(StreamServerInterceptor(validator, opts...)),
+	)
+	pb.RegisterIdentityServiceServer(s, principalService{})
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewIdentityServiceClient(conn)
+}
+
+func TestServerInterceptors(t *testing.T) {
+	validator := validatorFunc(func(ctx context.Context, token string) (*identity.SessionResult, error) {
+		switch token {
+		case "good":
+			return &identity.SessionResult{Valid: true, User: &pb.User{Id: "u1"}}, nil
+		case "down":
+			return nil, status.Error(codes.Unavailable, "dial tcp 10.0.0.7:443: connection refused")
+		case "slow":
+			return nil, context.DeadlineExceeded
+		default:
+			return nil, &identity.InvalidSessionError{Reason: "session revoked"}
+		}
+	})
+	client := newInterceptedClient(t, validator, WithExemptMethods(pb.IdentityService_GetJWKS_FullMethodName))
+
+	tests := []struct {
+		name          string
+		authorization string
+		code          codes.Code
+		message       string
+	}{
+		{"valid token", "Bearer good", codes.OK, ""},
+		{"lowercase scheme", "bearer good", codes.OK, ""},
+		{"missing token", "", codes.Unauthenticated, "missing bearer token in authorization metadata"},
+		{"other scheme", "Basic dXNlcjpwYXNz", codes.Unauthenticated, "missing bearer token in authorization metadata"},
+		{"invalid token", "Bearer bad", codes.Unauthenticated, "invalid session"},
+		{"unavailable", "Bearer down", codes.Unavailable, "identity service unavailable"},
+		{"deadline", "Bearer slow", codes.Unavailable, "identity service unavailable"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.authorization != "" {
+				ctx = metadata.AppendToOutgoingContext(ctx, "authorization", tt.authorization)
+			}
+			user, err := client.GetUser(ctx, &pb.GetUserRequest{})
+			if st := status.Convert(err); st.Code() != tt.code || st.Message() != tt.message {
+				t.Fatalf("unary: got %v, want %s %q", err, tt.code, tt.message)
+			}
+			if tt.code == codes.OK && user.GetId() != "u1" {
+				t.Fatalf("unary: handler saw user %q, want u1", user.GetId())
+			}
+
+			stream, err := client.WatchRevocations(ctx, &pb.WatchRevocationsRequest{})
+			if err == nil {
+				var ev *pb.RevocationEvent
+				ev, err = stream.Recv()
+				if err == nil && ev.GetUserId() != "u1" {
+					t.Fatalf("stream: handler saw user %q, want u1", ev.GetUserId())
+				}
+			}
+			if st := status.Convert(err); st.Code() != tt.code || st.Message() != tt.message {
+				t.Fatalf("stream: got %v, want %s %q", err, tt.code, tt.message)
+			}
+		})
+	}
+}
+
+func TestServerInterceptorsExemptMethods(t *testing.T) {
+	validator := validatorFunc(func(context.Context, string) (*identity.SessionResult, error) {
+		t.Error("an exempt method was authenticated")
+		return nil, &identity.InvalidSessionError{Reason: "test"}
+	})
+	client := newInterceptedClient(t, validator, WithExemptMethods(pb.IdentityService_GetUser_FullMethodName))
+
+	user, err := client.GetUser(context.Background(), &pb.GetUserRequest{})
+	if err != nil || user.GetId() != "anonymous" {
+		t.Fatalf("exempt method: got %v, %v", user, err)
+	}
+	if _, err := client.GetTenant(context.Background(), &pb.GetTenantRequest{}); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("other method: got %v, want Unauthenticated", err)
+	}
+}
@@ -1,49 +1,287 @@
 package middleware
 
 import (
-	"context"
 	"net/http"
+	"path"
 	"strings"
 
-	"github.com/kodeart/go-problem/v2"
 	"github.com/kodeart/identity-sdk-go"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
 )
 
+// Option configures the IdentityAuth middleware.
+type Option func(*config)
+
+type config struct {
+	extractors   []TokenExtractor
+	optional     bool
+	skippers     []Skipper
+	errorHandler ErrorHandler
+	failOpen     []Skipper
+	onDegraded   func(r *http.Request, mode identity.DegradationMode)
+	metrics      identity.Metrics
+	tracer       trace.Tracer
+	logger       *zerolog.Logger
+}
+
+// Skipper reports whether the request bypasses the authentication.
+type Skipper func(r *http.Request) bool
+
+// WithTokenExtractors sets the chain used to find the session token,
+// the first extractor returning a token wins. Defaults to FromBearer.
+func WithTokenExtractors(extractors ...TokenExtractor) Option {
+	return func(c *config) {
+		c.extractors = extractors
+	}
+}
+
+// Optional lets requests without a valid session through, without
+// a principal and marked with identity.Anonymous. A principal is
+// attached only when a valid token is present. A token that cannot be
+// validated because the Identity Service is unavailable is still
+// rejected, unless the route is allowed by FailOpen.
+func Optional() Option {
+	return func(c *config) {
+		c.optional = true
+	}
+}
+
+// WithSkipper bypasses the authentication for the requests
+// the skipper reports, e.g. health checks.
+func WithSkipper(skip Skipper) Option {
+	return func(c *config) {
+		c.skippers = append(c.skippers, skip)
+	}
+}
+
+// SkipPaths bypasses the authentication for the request paths matching any of the patterns.
+func SkipPaths(patterns ...string) Option {
+	return WithSkipper(MatchPaths(patterns...))
+}
+
+// MatchPaths reports the request paths matching any of the patterns.
+// Patterns use the path.Match syntax, except a trailing "/*" which
+// matches everything below the prefix, e.g. "/healthz" or "/public/*".
+// The path is cleaned first, so "/public/../admin" does not match "/public/*".
+func MatchPaths(patterns ...string) Skipper {
+	return func(r *http.Request) bool {
+		p := cleanPath(r.URL.Path)
+		for _, pattern := range patterns {
+			if prefix, ok := strings.CutSuffix(pattern, "/*"); ok {
+				if p == prefix || strings.HasPrefix(p, prefix+"/") {
+					return true
+				}
+				continue
+			}
+			if ok, _ := path.Match(pattern, p); ok {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// cleanPath resolves the dot segments and duplicate slashes
+// of the request path, keeping its trailing slash.
+func cleanPath(p string) string {
+	if p == "" {
+		return "/"
+	}
+	if p[0] != '/' {
+		p = "/" + p
+	}
+	cleaned := path.Clean(p)
+	if strings.HasSuffix(p, "/") && cleaned != "/" {
+		cleaned += "/"
+	}
+	return cleaned
+}
+
+// FailOpen lets the safe (GET, HEAD and OPTIONS) requests to the routes
+// through, marked with identity.Anonymous, while the Identity Service is
+// unavailable. Every other request keeps failing closed.
+// The routes are usually given by MatchPaths.
+func FailOpen(routes Skipper) Option {
+	return func(c *config) {
+		c.failOpen = append(c.failOpen, routes)
+	}
+}
+
+// OnDegraded is called for every request served in a degraded mode,
+// e.g. to count them in the metrics.
+func OnDegraded(fn func(r *http.Request, mode identity.DegradationMode)) Option {
+	return func(c *config) {
+		c.onDegraded = fn
+	}
+}
+
+// WithMetrics counts the requests by identity.AuthOutcome.
+// Skipped requests are not counted.
+func WithMetrics(m identity.Metrics) Option {
+	return func(c *config) {
+		c.metrics = m
+	}
+}
+
+// WithTracerProvider creates a span for the authentication of every
+// request, carrying its identity.AttrOutcome. The span is the parent
+// of the identity.Client spans, and ends before the next handler runs.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(c *config) {
+		c.tracer = tp.Tracer(identity.TracerName)
+	}
+}
+
+// WithLogger sets the logger of the middleware, defaults to the global zerolog logger.
+func WithLogger(l *zerolog.Logger) Option {
+	return func(c *config) {
+		c.logger = l
+	}
+}
+
 // IdentityAuth is the core part of the identification of
 // any user against the configured external service provider.
 // This middleware is what is imported in all future projects
 // to resolve the user identity.
-func IdentityAuth(client *identity.Client) func(http.Handler) http.Handler {
+//
+// The validator is usually the *identity.Client, see
+// IdentityAuthLocalFirst to verify the tokens offline.
+func IdentityAuth(validator identity.SessionValidator) func(http.Handler) http.Handler {
+	return IdentityAuthWithOptions(validator)
+}
+
+// IdentityAuthWithOptions is IdentityAuth with a custom configuration.
+func IdentityAuthWithOptions(validator identity.SessionValidator, opts ...Option) func(http.Handler) http.Handler {
+	cfg := &config{
+		extractors:   []TokenExtractor{FromBearer()},
+		errorHandler: DefaultErrorHandler,
+		tracer:       noop.NewTracerProvider().Tracer(identity.TracerName),
+		logger:       &log.Logger,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			authHeader := r.Header.Get("Authorization")
-			if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
-				problem.New().
-					WithDetail("Missing auth header").
-					WithTitle("Identity Not Authorized").
-					WithStatus(http.StatusUnauthorized).
-					WithInstance(r.URL.String()).
-					WithExtension("error", "Provide the authorization header").
-					JSON(w)
-
+			if cfg.skip(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			ctx, span := cfg.tracer.Start(r.Context(), "identity.IdentityAuth", trace.WithAttributes(
+				attribute.String("http.request.method", r.Method),
+				attribute.String("url.path", r.URL.Path),
+			))
+			token, ok := cfg.extractToken(r)
+			if !ok {
+				cfg.observe(span, identity.AuthMissing)
+			}
+			if !ok && cfg.optional {
+				next.ServeHTTP(w, r.WithContext(identity.WithAnonymous(r.Context(), identity.Anonymous{Reason: "no token"})))
+				return
+			}
+			if !ok {
+				cfg.errorHandler(w, r, ErrMissingToken)
+				return
+			}
+			session, err := validator.ValidateSession(ctx, token)
+			if identity.IsUnavailable(err) && cfg.canFailOpen(r) {
+				cfg.observe(span, identity.AuthDegraded)
+				cfg.degraded(r, identity.DegradedFailOpen)
+				next.ServeHTTP(w, r.WithContext(identity.WithAnonymous(r.Context(), identity.Anonymous{Reason: "identity service unavailable"})))
+				return
+			}
+			switch {
+			case identity.IsUnavailable(err):
+				cfg.observe(span, identity.AuthUnavailable)
+			case err != nil:
+				cfg.observe(span, identity.AuthInvalid)
+			case session.Degraded:
+				span.SetAttributes(identity.AttrTenant.String(session.User.GetTenantId()))
+				cfg.observe(span, identity.AuthDegraded)
+			default:
+				span.SetAttributes(identity.AttrTenant.String(session.User.GetTenantId()))
+				cfg.observe(span, identity.AuthAuthorized)
+			}
+			// A token that could not be checked is not an invalid one:
+			// an unavailable service fails closed unless FailOpen allows it.
+			if err != nil && cfg.optional && !identity.IsUnavailable(err) {
+				next.ServeHTTP(w, r.WithContext(identity.WithAnonymous(r.Context(), identity.Anonymous{Reason: "invalid token"})))
 				return
 			}
-			token := strings.TrimPrefix(authHeader, "Bearer ")
-			user, err := client.ValidateSession(r.Context(), token)
 			if err != nil {
-				problem.New().
-					WithDetail("Invalid Token").
-					WithTitle("Identity Not Authorized").
-					WithStatus(http.StatusUnauthorized).
-					WithInstance(r.URL.String()).
-					WithExtension("error", err.Error()).
-					JSON(w)
-
+				cfg.errorHandler(w, r, err)
 				return
 			}
 
-			ctx := context.WithValue(r.Context(), identity.UserContextKey, user)
-			next.ServeHTTP(w, r.WithContext(ctx))
+			if session.Degraded {
+				cfg.degraded(r, identity.DegradedStaleCache)
+			}
+			next.ServeHTTP(w, r.WithContext(identity.WithPrincipal(r.Context(), identity.NewPrincipal(token, session))))
 		})
 	}
 }
+
+// IdentityAuthLocalFirst verifies the tokens offline with the verifier
+// and only asks the Identity Service about the tokens it cannot decide on.
+// Set the client as the VerifierConfig.Revocations of the verifier
+// so revoked tokens are rejected offline too, see identity.LocalFirst.
+func IdentityAuthLocalFirst(verifier *identity.Verifier, client *identity.Client) func(http.Handler) http.Handler {
+	return IdentityAuth(identity.LocalFirst(verifier, client))
+}
+
+func (c *config) extractToken(r *http.Request) (string, bool) {
+	for _, extract := range c.extractors {
+		if token, ok := extract(r); ok {
+			return token, true
+		}
+	}
+	return "", false
+}
+
+func (c *config) skip(r *http.Request) bool {
+	for _, skip := range c.skippers {
+		if skip(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// canFailOpen reports whether the request is safe and its route is allow-listed by FailOpen.
+func (c *config) canFailOpen(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+	default:
+		return false
+	}
+	for _, route := range c.failOpen {
+		if route(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// observe records the outcome of the request and ends its span.
+func (c *config) observe(span trace.Span, outcome identity.AuthOutcome) {
+	if c.metrics != nil {
+		c.metrics.ObserveAuth(outcome)
+	}
+	span.SetAttributes(identity.AttrOutcome.String(string(outcome)))
+	if outcome == identity.AuthUnavailable {
+		span.SetStatus(codes.Error, string(outcome))
+	}
+	span.End()
+}
+
+func (c *config) degraded(r *http.Request, mode identity.DegradationMode) {
+	c.logger.Warn().Str("mode", string(mode)).Str("path", r.URL.Path).Msg("serving request in degraded mode")
+	if c.onDegraded != nil {
+		c.onDegraded(r, mode)
+	}
+}
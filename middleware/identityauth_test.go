@@ -0,0 +1,257 @@
+package middleware
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kodeart/identity-sdk-go"
+	pb "github.com/kodeart/identity-sdk-go/proto/v1"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// validatorFunc adapts a function to identity.SessionValidator.
+type validatorFunc func(ctx context.Context, token string) (*identity.SessionResult, error)
+
+func (f validatorFunc) ValidateSession(ctx context.Context, token string) (*identity.SessionResult, error) {
+	return f(ctx, token)
+}
+
+// okHandler records whether the request reached it.
+func okHandler(reached *bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*reached = true
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+func serve(h func(http.Handler) http.Handler, next http.Handler, r *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h(next).ServeHTTP(w, r)
+	return w
+}
+
+func newVerifier(t *testing.T) *identity.Verifier {
+	t.Helper()
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwks, err := json.Marshal(map[string]any{"keys": []map[string]string{{
+		"kty": "OKP", "crv": "Ed25519", "kid": "k1", "alg": "EdDSA",
+		"x": base64.RawURLEncoding.EncodeToString(pub),
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, jwks, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	v, err := identity.NewVerifier(context.Background(), identity.VerifierConfig{Keys: identity.JWKSFile(path)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { v.Close() })
+	return v
+}
+
+func TestIdentityAuthVerifierUnverifiableToken(t *testing.T) {
+	auth := IdentityAuth(newVerifier(t))
+	for _, token := range []string{"garbage", "a.b.c"} {
+		var reached bool
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Authorization", "Bearer "+token)
+		w := serve(auth, okHandler(&reached), r)
+		if w.Code != http.StatusUnauthorized || reached {
+			t.Errorf("token %q: got %d, reached=%t, want 401", token, w.Code, reached)
+		}
+	}
+}
+
+func TestMatchPaths(t *testing.T) {
+	match := MatchPaths("/healthz", "/public/*", "/docs/*.html")
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/healthz", true},
+		{"/healthz/", false},
+		{"/healthz/x", false},
+		{"/public", true},
+		{"/public/", true},
+		{"/public/css/app.css", true},
+		{"/publicity", false},
+		{"/docs/index.html", true},
+		{"/docs/a/index.html", false},
+		{"/public/../admin", false},
+		{"//public/../admin", false},
+		{"/admin/../public/x", true},
+		{"/public/./x", true},
+		{"/admin", false},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.URL.Path = tt.path
+		if got := match(r); got != tt.want {
+			t.Errorf("MatchPaths(%q) = %t, want %t", tt.path, got, tt.want)
+		}
+	}
+	// Encoded dot segments are decoded into the path by net/url.
+	if match(httptest.NewRequest(http.MethodGet, "/public/%2e%2e/admin", nil)) {
+		t.Error("MatchPaths matches an encoded traversal out of /public")
+	}
+}
+
+func TestSkipPathsTraversal(t *testing.T) {
+	auth := IdentityAuthWithOptions(validatorFunc(func(context.Context, string) (*identity.SessionResult, error) {
+		return nil, &identity.InvalidSessionError{Reason: "test"}
+	}), SkipPaths("/public/*"))
+
+	var reached bool
+	w := serve(auth, okHandler(&reached), httptest.NewRequest(http.MethodGet, "/public/../admin", nil))
+	if w.Code != http.StatusUnauthorized || reached {
+		t.Fatalf("/public/../admin: got %d, reached=%t, want 401", w.Code, reached)
+	}
+	reached = false
+	if w := serve(auth, okHandler(&reached), httptest.NewRequest(http.MethodGet, "/public/app.css", nil)); !reached {
+		t.Fatalf("/public/app.css: got %d, want the skipped request through", w.Code)
+	}
+}
+
+// identityHandler records the principal or the anonymous marker of the request.
+func identityHandler(principal **identity.Principal, anonymous *identity.Anonymous) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*principal, _ = identity.PrincipalFromContext(r.Context())
+		*anonymous, _ = identity.AnonymousFromContext(r.Context())
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+func TestOptional(t *testing.T) {
+	auth := IdentityAuthWithOptions(validatorFunc(func(_ context.Context, token string) (*identity.SessionResult, error) {
+		if token != "good" {
+			return nil, &identity.InvalidSessionError{Reason: "test"}
+		}
+		return &identity.SessionResult{Valid: true, User: &pb.User{Id: "u1", TenantId: "t1"}}, nil
+	}), Optional())
+
+	tests := []struct {
+		name   string
+		header string
+		user   string
+		reason string
+	}{
+		{"valid token", "Bearer good", "u1", ""},
+		{"missing token", "", "", "no token"},
+		{"invalid token", "Bearer bad", "", "invalid token"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", nil)
+			if tt.header != "" {
+				r.Header.Set("Authorization", tt.header)
+			}
+			var (
+				p *identity.Principal
+				a identity.Anonymous
+			)
+			w := serve(auth, identityHandler(&p, &a), r)
+			if w.Code != http.StatusNoContent {
+				t.Fatalf("got %d, want the request through", w.Code)
+			}
+			if tt.user != "" {
+				if p == nil || p.User.GetId() != tt.user || p.Token != "good" {
+					t.Fatalf("principal = %+v, want user %q", p, tt.user)
+				}
+				return
+			}
+			if p != nil || a.Reason != tt.reason {
+				t.Fatalf("principal = %+v, anonymous = %q, want anonymous %q", p, a.Reason, tt.reason)
+			}
+		})
+	}
+}
+
+func TestFailOpen(t *testing.T) {
+	unavailable := validatorFunc(func(context.Context, string) (*identity.SessionResult, error) {
+		return nil, status.Error(codes.Unavailable, "down")
+	})
+	nop := zerolog.Nop()
+	tests := []struct {
+		name     string
+		opts     []Option
+		method   string
+		path     string
+		status   int
+		degraded bool
+	}{
+		{"GET on an allowed route", nil, http.MethodGet, "/catalog/1", http.StatusNoContent, true},
+		{"HEAD on an allowed route", nil, http.MethodHead, "/catalog/1", http.StatusNoContent, true},
+		{"OPTIONS on an allowed route", nil, http.MethodOptions, "/catalog/1", http.StatusNoContent, true},
+		{"POST on an allowed route", nil, http.MethodPost, "/catalog/1", http.StatusServiceUnavailable, false},
+		{"DELETE on an allowed route", nil, http.MethodDelete, "/catalog/1", http.StatusServiceUnavailable, false},
+		{"GET on another route", nil, http.MethodGet, "/orders/1", http.StatusServiceUnavailable, false},
+		{"optional GET on another route", []Option{Optional()}, http.MethodGet, "/orders/1", http.StatusServiceUnavailable, false},
+		{"optional POST on an allowed route", []Option{Optional()}, http.MethodPost, "/catalog/1", http.StatusServiceUnavailable, false},
+		{"optional GET on an allowed route", []Option{Optional()}, http.MethodGet, "/catalog/1", http.StatusNoContent, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var modes []identity.DegradationMode
+			opts := append([]Option{
+				FailOpen(MatchPaths("/catalog/*")),
+				OnDegraded(func(_ *http.Request, mode identity.DegradationMode) { modes = append(modes, mode) }),
+				WithLogger(&nop),
+			}, tt.opts...)
+			auth := IdentityAuthWithOptions(unavailable, opts...)
+
+			r := httptest.NewRequest(tt.method, tt.path, nil)
+			r.Header.Set("Authorization", "Bearer token")
+			var (
+				p *identity.Principal
+				a identity.Anonymous
+			)
+			w := serve(auth, identityHandler(&p, &a), r)
+			if w.Code != tt.status {
+				t.Fatalf("got %d, want %d", w.Code, tt.status)
+			}
+			if tt.degraded != (len(modes) == 1 && modes[0] == identity.DegradedFailOpen) {
+				t.Fatalf("degraded modes = %v, want fail-open %t", modes, tt.degraded)
+			}
+			if tt.degraded && (p != nil || a.Reason != "identity service unavailable") {
+				t.Fatalf("principal = %+v, anonymous = %q, want an anonymous request", p, a.Reason)
+			}
+		})
+	}
+}
+
+func TestStaleCacheDegraded(t *testing.T) {
+	nop := zerolog.Nop()
+	var modes []identity.DegradationMode
+	auth := IdentityAuthWithOptions(validatorFunc(func(context.Context, string) (*identity.SessionResult, error) {
+		return &identity.SessionResult{Valid: true, User: &pb.User{Id: "u1"}, Degraded: true}, nil
+	}), OnDegraded(func(_ *http.Request, mode identity.DegradationMode) { modes = append(modes, mode) }), WithLogger(&nop))
+
+	r := httptest.NewRequest(http.MethodPost, "/orders", nil)
+	r.Header.Set("Authorization", "Bearer token")
+	var (
+		p *identity.Principal
+		a identity.Anonymous
+	)
+	if w := serve(auth, identityHandler(&p, &a), r); w.Code != http.StatusNoContent || p == nil {
+		t.Fatalf("got %d, principal %+v, want the stale session through", w.Code, p)
+	}
+	if len(modes) != 1 || modes[0] != identity.DegradedStaleCache {
+		t.Fatalf("degraded modes = %v, want stale-cache", modes)
+	}
+}
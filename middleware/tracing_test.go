@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kodeart/identity-sdk-go"
+	pb "github.com/kodeart/identity-sdk-go/proto/v1"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"google.golang.org/grpc"
+)
+
+type sessionService struct {
+	pb.UnimplementedIdentityServiceServer
+}
+
+func (sessionService) ValidateSession(_ context.Context, req *pb.ValidateSessionRequest) (*pb.ValidateSessionResponse, error) {
+	return &pb.ValidateSessionResponse{Valid: true, User: &pb.User{Id: "u1", TenantId: "t1"}}, nil
+}
+
+func TestIdentityAuthTracing(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := grpc.NewServer()
+	pb.RegisterIdentityServiceServer(s, sessionService{})
+	go s.Serve(lis)
+	defer s.Stop()
+
+	exp := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+	defer tp.Shutdown(context.Background())
+	client, err := identity.NewClient(lis.Addr().String(), identity.WithTracerProvider(tp))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	auth := IdentityAuthWithOptions(client, WithTracerProvider(tp))
+	r := httptest.NewRequest(http.MethodGet, "/docs", nil)
+	r.Header.Set("Authorization", "Bearer token")
+	var reached bool
+	if w := serve(auth, okHandler(&reached), r); !reached {
+		t.Fatalf("got %d, want the request through", w.Code)
+	}
+
+	spans := exp.GetSpans()
+	if len(spans) != 2 || spans[0].Name != "identity.ValidateSession" || spans[1].Name != "identity.IdentityAuth" {
+		t.Fatalf("spans = %v, want the ValidateSession span and its IdentityAuth parent", spans.Snapshots())
+	}
+	call, request := spans[0], spans[1]
+	if call.Parent.SpanID() != request.SpanContext.SpanID() {
+		t.Error("the ValidateSession span is not a child of the IdentityAuth span")
+	}
+	want := map[attribute.Key]string{
+		"http.request.method": http.MethodGet,
+		"url.path":            "/docs",
+		identity.AttrTenant:   "t1",
+		identity.AttrOutcome:  string(identity.AuthAuthorized),
+	}
+	got := make(map[attribute.Key]string)
+	for _, kv := range request.Attributes {
+		got[kv.Key] = kv.Value.Emit()
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("IdentityAuth span: %s = %q, want %q", k, got[k], v)
+		}
+	}
+}
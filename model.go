@@ -0,0 +1,207 @@
+package identity
+
+import (
+	"slices"
+	"time"
+
+	pb "github.com/kodeart/identity-sdk-go/proto/v1"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// User is the SDK model of pb.User, so services do not
+// depend on the generated protobuf types, see Client.Models
+// and UserModelFromContext.
+//
+// Unset timestamps are the zero time and unset metadata is nil.
+// Metadata values follow the JSON data model of structpb, so
+// numbers come back as float64 and nested objects as map[string]any.
+type User struct {
+	ID          string
+	Email       string
+	TenantID    string
+	TenantSlug  string
+	DisplayName string
+	Metadata    map[string]any
+	LastLogin   time.Time
+	CreatedAt   time.Time
+	Roles       []string
+	Permissions []string
+}
+
+// Role is a named set of permissions of a Tenant.
+type Role struct {
+	Name        string
+	Permissions []string
+}
+
+// Tenant is the SDK model of pb.Tenant.
+type Tenant struct {
+	ID       string
+	Name     string
+	Slug     string
+	Settings map[string]any
+	Roles    []Role
+}
+
+// Session is the SDK model of pb.AuthenticateResponse.
+type Session struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresAt    time.Time
+	// User is the signed-in user, nil when the response carries none.
+	User *User
+}
+
+// UserFromProto converts the user, nil stays nil.
+func UserFromProto(u *pb.User) *User {
+	if u == nil {
+		return nil
+	}
+	return &User{
+		ID:          u.GetId(),
+		Email:       u.GetEmail(),
+		TenantID:    u.GetTenantId(),
+		TenantSlug:  u.GetTenantSlug(),
+		DisplayName: u.GetDisplayName(),
+		Metadata:    structMap(u.GetMetadata()),
+		LastLogin:   timeOf(u.GetLastLogin()),
+		CreatedAt:   timeOf(u.GetCreatedAt()),
+		Roles:       slices.Clone(u.GetRoles()),
+		Permissions: slices.Clone(u.GetPermissions()),
+	}
+}
+
+// Proto converts the user back. It fails if the metadata
+// holds a value structpb cannot represent.
+func (u *User) Proto() (*pb.User, error) {
+	if u == nil {
+		return nil, nil
+	}
+	metadata, err := mapStruct(u.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.User{
+		Id:          u.ID,
+		Email:       u.Email,
+		TenantId:    u.TenantID,
+		TenantSlug:  u.TenantSlug,
+		DisplayName: u.DisplayName,
+		Metadata:    metadata,
+		LastLogin:   timestampOf(u.LastLogin),
+		CreatedAt:   timestampOf(u.CreatedAt),
+		Roles:       slices.Clone(u.Roles),
+		Permissions: slices.Clone(u.Permissions),
+	}, nil
+}
+
+// TenantFromProto converts the tenant, nil stays nil.
+func TenantFromProto(t *pb.Tenant) *Tenant {
+	if t == nil {
+		return nil
+	}
+	var roles []Role
+	if t.GetRoles() != nil {
+		roles = make([]Role, len(t.GetRoles()))
+		for i, r := range t.GetRoles() {
+			roles[i] = Role{Name: r.GetName(), Permissions: slices.Clone(r.GetPermissions())}
+		}
+	}
+	return &Tenant{
+		ID:       t.GetId(),
+		Name:     t.GetName(),
+		Slug:     t.GetSlug(),
+		Settings: structMap(t.GetSettings()),
+		Roles:    roles,
+	}
+}
+
+// Proto converts the tenant back. It fails if the settings
+// hold a value structpb cannot represent.
+func (t *Tenant) Proto() (*pb.Tenant, error) {
+	if t == nil {
+		return nil, nil
+	}
+	settings, err := mapStruct(t.Settings)
+	if err != nil {
+		return nil, err
+	}
+	var roles []*pb.Role
+	if t.Roles != nil {
+		roles = make([]*pb.Role, len(t.Roles))
+		for i, r := range t.Roles {
+			roles[i] = &pb.Role{Name: r.Name, Permissions: slices.Clone(r.Permissions)}
+		}
+	}
+	return &pb.Tenant{
+		Id:       t.ID,
+		Name:     t.Name,
+		Slug:     t.Slug,
+		Settings: settings,
+		Roles:    roles,
+	}, nil
+}
+
+// SessionFromProto converts the result of the Authenticate
+// and RefreshSession calls, nil stays nil.
+func SessionFromProto(s *pb.AuthenticateResponse) *Session {
+	if s == nil {
+		return nil
+	}
+	return &Session{
+		AccessToken:  s.GetAccessToken(),
+		RefreshToken: s.GetRefreshToken(),
+		ExpiresAt:    timeOf(s.GetExpiresAt()),
+		User:         UserFromProto(s.GetUser()),
+	}
+}
+
+// Proto converts the session back. It fails if the user
+// metadata holds a value structpb cannot represent.
+func (s *Session) Proto() (*pb.AuthenticateResponse, error) {
+	if s == nil {
+		return nil, nil
+	}
+	user, err := s.User.Proto()
+	if err != nil {
+		return nil, err
+	}
+	return &pb.AuthenticateResponse{
+		AccessToken:  s.AccessToken,
+		RefreshToken: s.RefreshToken,
+		ExpiresAt:    timestampOf(s.ExpiresAt),
+		User:         user,
+	}, nil
+}
+
+// timeOf maps an unset timestamp to the zero time.
+func timeOf(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
+
+// timestampOf maps the zero time to an unset timestamp.
+func timestampOf(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+// structMap maps an unset struct to nil, and an empty one to an empty map.
+func structMap(s *structpb.Struct) map[string]any {
+	if s == nil {
+		return nil
+	}
+	return s.AsMap()
+}
+
+func mapStruct(m map[string]any) (*structpb.Struct, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return structpb.NewStruct(m)
+}
@@ -0,0 +1,130 @@
+package identity
+
+import (
+	"context"
+
+	pb "github.com/kodeart/identity-sdk-go/proto/v1"
+)
+
+// Models exposes the Client calls with the SDK models
+// instead of the generated protobuf types:
+//
+//	user, err := client.Models().GetUser(ctx, tenantID, userID)
+type Models struct {
+	client *Client
+}
+
+// Models returns the model based view of the Client.
+func (c *Client) Models() *Models {
+	return &Models{client: c}
+}
+
+// GetUser returns the user of the tenant, see Client.GetUser.
+func (m *Models) GetUser(ctx context.Context, tenantID, userID string) (*User, error) {
+	u, err := m.client.GetUser(ctx, tenantID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return UserFromProto(u), nil
+}
+
+// CreateUser creates the user from its Email, TenantID, DisplayName
+// and Metadata, see Client.CreateUser. The password is optional.
+func (m *Models) CreateUser(ctx context.Context, user *User, password string) (*User, error) {
+	req := &pb.CreateUserRequest{Password: password}
+	if user != nil {
+		metadata, err := mapStruct(user.Metadata)
+		if err != nil {
+			return nil, &ValidationError{
+				Method:     "CreateUser",
+				Violations: []FieldViolation{{Field: "metadata", Description: err.Error()}},
+			}
+		}
+		req.Email = user.Email
+		req.TenantId = user.TenantID
+		req.DisplayName = user.DisplayName
+		req.Metadata = metadata
+	}
+	u, err := m.client.CreateUser(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return UserFromProto(u), nil
+}
+
+// UpdateUser sends the update built with Client.UpdateUser.
+func (m *Models) UpdateUser(ctx context.Context, update *UserUpdate) (*User, error) {
+	u, err := update.Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return UserFromProto(u), nil
+}
+
+// GetTenantByID returns the tenant with the id, see Client.GetTenantByID.
+func (m *Models) GetTenantByID(ctx context.Context, id string) (*Tenant, error) {
+	t, err := m.client.GetTenantByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return TenantFromProto(t), nil
+}
+
+// GetTenantBySlug returns the tenant with the slug, see Client.GetTenantBySlug.
+func (m *Models) GetTenantBySlug(ctx context.Context, slug string) (*Tenant, error) {
+	t, err := m.client.GetTenantBySlug(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+	return TenantFromProto(t), nil
+}
+
+// CreateTenant creates a tenant, see Client.CreateTenant.
+func (m *Models) CreateTenant(ctx context.Context, name, slug string) (*Tenant, error) {
+	t, err := m.client.CreateTenant(ctx, name, slug)
+	if err != nil {
+		return nil, err
+	}
+	return TenantFromProto(t), nil
+}
+
+// AuthenticateWithCredentials signs the user in, see Client.AuthenticateWithCredentials.
+func (m *Models) AuthenticateWithCredentials(ctx context.Context, tenantSlug, email, password string) (*Session, error) {
+	s, err := m.client.AuthenticateWithCredentials(ctx, tenantSlug, email, password)
+	if err != nil {
+		return nil, err
+	}
+	return SessionFromProto(s), nil
+}
+
+// AuthenticateWithProvider signs the user in, see Client.AuthenticateWithProvider.
+func (m *Models) AuthenticateWithProvider(ctx context.Context, tenantSlug, providerToken string) (*Session, error) {
+	s, err := m.client.AuthenticateWithProvider(ctx, tenantSlug, providerToken)
+	if err != nil {
+		return nil, err
+	}
+	return SessionFromProto(s), nil
+}
+
+// RefreshSession exchanges the refresh token, see Client.RefreshSession.
+func (m *Models) RefreshSession(ctx context.Context, refreshToken string) (*Session, error) {
+	s, err := m.client.RefreshSession(ctx, refreshToken)
+	if err != nil {
+		return nil, err
+	}
+	return SessionFromProto(s), nil
+}
+
+// UserModel returns the principal user as the SDK model.
+func (p *Principal) UserModel() *User {
+	return UserFromProto(p.User)
+}
+
+// UserModelFromContext is UserFromContext returning the SDK model.
+func UserModelFromContext(ctx context.Context) (*User, bool) {
+	u, ok := UserFromContext(ctx)
+	if !ok {
+		return nil, false
+	}
+	return UserFromProto(u), true
+}
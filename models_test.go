@@ -0,0 +1,127 @@
+package identity
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "github.com/kodeart/identity-sdk-go/proto/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestModels(t *testing.T) {
+	expires := time.Now().Add(time.Hour).Truncate(time.Second)
+	metadata, _ := structpb.NewStruct(map[string]any{"plan": "pro"})
+	addr := startServer(t, &fakeIdentityService{
+		authenticate: func(context.Context, *pb.AuthenticateRequest) (*pb.AuthenticateResponse, error) {
+			return &pb.AuthenticateResponse{AccessToken: "access", RefreshToken: "refresh", ExpiresAt: timestamppb.New(expires)}, nil
+		},
+		validateSession: func(context.Context, *pb.ValidateSessionRequest) (*pb.ValidateSessionResponse, error) {
+			return &pb.ValidateSessionResponse{Valid: true, User: &pb.User{Id: "u1", TenantId: "t1", Metadata: metadata}}, nil
+		},
+	})
+	c, err := NewClient(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	session, err := c.Models().AuthenticateWithCredentials(context.Background(), "acme", "ada@example.com", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.AccessToken != "access" || session.RefreshToken != "refresh" || !session.ExpiresAt.Equal(expires) {
+		t.Errorf("session = %+v", session)
+	}
+
+	res, err := c.ValidateSession(context.Background(), session.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := WithPrincipal(context.Background(), NewPrincipal(session.AccessToken, res))
+	user, ok := UserModelFromContext(ctx)
+	if !ok || user.ID != "u1" || user.TenantID != "t1" || user.Metadata["plan"] != "pro" {
+		t.Errorf("UserModelFromContext = %+v, %t", user, ok)
+	}
+	p, _ := PrincipalFromContext(ctx)
+	if got := p.UserModel(); got.ID != "u1" {
+		t.Errorf("Principal.UserModel = %+v", got)
+	}
+	if _, ok := UserModelFromContext(context.Background()); ok {
+		t.Error("UserModelFromContext reports a user on an unauthenticated context")
+	}
+}
+
+func TestModelRoundTrip(t *testing.T) {
+	created := timestamppb.New(time.Date(2024, 3, 1, 12, 0, 0, 123456789, time.UTC))
+	metadata, err := structpb.NewStruct(map[string]any{
+		"plan":   "pro",
+		"limits": map[string]any{"api": 100, "burst": 1.5},
+		"tags":   []any{"beta", true, nil},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := &pb.User{
+		Id:          "u1",
+		Email:       "ada@example.com",
+		TenantId:    "t1",
+		TenantSlug:  "acme",
+		DisplayName: "Ada",
+		Metadata:    metadata,
+		LastLogin:   timestamppb.New(created.AsTime().Add(time.Hour)),
+		CreatedAt:   created,
+		Roles:       []string{"admin"},
+		Permissions: []string{"docs:read", "docs:edit"},
+	}
+
+	t.Run("user", func(t *testing.T) {
+		for _, u := range []*pb.User{user, {Id: "u2"}} {
+			got, err := UserFromProto(u).Proto()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !proto.Equal(got, u) {
+				t.Errorf("round trip of %v gave %v", u, got)
+			}
+		}
+	})
+	t.Run("tenant", func(t *testing.T) {
+		for _, tn := range []*pb.Tenant{
+			{
+				Id: "t1", Name: "Acme", Slug: "acme", Settings: metadata,
+				Roles: []*pb.Role{{Name: "admin", Permissions: []string{"*"}}, {Name: "viewer"}},
+			},
+			{Id: "t2"},
+		} {
+			got, err := TenantFromProto(tn).Proto()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !proto.Equal(got, tn) {
+				t.Errorf("round trip of %v gave %v", tn, got)
+			}
+		}
+	})
+	t.Run("session", func(t *testing.T) {
+		for _, s := range []*pb.AuthenticateResponse{
+			{AccessToken: "access", RefreshToken: "refresh", ExpiresAt: created, User: user},
+			{AccessToken: "access"},
+		} {
+			got, err := SessionFromProto(s).Proto()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !proto.Equal(got, s) {
+				t.Errorf("round trip of %v gave %v", s, got)
+			}
+		}
+	})
+	t.Run("nil", func(t *testing.T) {
+		if UserFromProto(nil) != nil || TenantFromProto(nil) != nil || SessionFromProto(nil) != nil {
+			t.Error("nil does not stay nil")
+		}
+	})
+}
@@ -0,0 +1,236 @@
+package identity
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
+)
+
+// Option configures the Client created by NewClient.
+type Option func(*options)
+
+type options struct {
+	authority      string
+	creds          credentials.TransportCredentials
+	mtls           *MTLSConfig
+	perRPC         credentials.PerRPCCredentials
+	insecurePerRPC bool
+	keepalive      keepalive.ClientParameters
+	retryPolicy    *RetryPolicy
+	connectTimeout time.Duration
+	minConnect     time.Duration
+	cache          *CacheConfig
+	degradation    DegradationPolicy
+	breaker        *BreakerConfig
+	methodBreakers map[string]BreakerConfig
+	logger         zerolog.Logger
+	metrics        Metrics
+	tracerProvider trace.TracerProvider
+	propagator     propagation.TextMapPropagator
+	dialOpts       []grpc.DialOption
+}
+
+// RetryPolicy mirrors the gRPC "retryPolicy" service config
+// and is applied to every method of the Identity Service.
+type RetryPolicy struct {
+	MaxAttempts          int
+	InitialBackoff       time.Duration
+	MaxBackoff           time.Duration
+	BackoffMultiplier    float64
+	RetryableStatusCodes []codes.Code
+}
+
+// DefaultRetryPolicy retries UNAVAILABLE responses
+// up to 5 times with an exponential backoff.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:          5,
+	InitialBackoff:       100 * time.Millisecond,
+	MaxBackoff:           time.Second,
+	BackoffMultiplier:    2.0,
+	RetryableStatusCodes: []codes.Code{codes.Unavailable},
+}
+
+// DefaultKeepalive pings the server every 10 seconds,
+// even when there are no active RPCs.
+var DefaultKeepalive = keepalive.ClientParameters{
+	Time:                10 * time.Second,
+	Timeout:             time.Second,
+	PermitWithoutStream: true,
+}
+
+func defaultOptions() *options {
+	rp := DefaultRetryPolicy
+	return &options{
+		creds:          insecure.NewCredentials(),
+		keepalive:      DefaultKeepalive,
+		retryPolicy:    &rp,
+		connectTimeout: 2 * time.Second,
+		logger:         log.Logger,
+		metrics:        nopMetrics{},
+	}
+}
+
+// WithAuthority sets the value used as the :authority pseudo-header
+// and as the server name in the authentication handshake.
+func WithAuthority(authority string) Option {
+	return func(o *options) {
+		o.authority = authority
+	}
+}
+
+// WithTransportCredentials sets the transport credentials of the channel.
+// Without this option or WithMTLS the connection is established in plaintext.
+func WithTransportCredentials(creds credentials.TransportCredentials) Option {
+	return func(o *options) {
+		o.creds = creds
+	}
+}
+
+// WithTLSConfig is a shorthand for WithTransportCredentials
+// using the provided TLS configuration.
+func WithTLSConfig(cfg *tls.Config) Option {
+	return WithTransportCredentials(credentials.NewTLS(cfg))
+}
+
+// WithKeepalive overrides the DefaultKeepalive parameters.
+func WithKeepalive(params keepalive.ClientParameters) Option {
+	return func(o *options) {
+		o.keepalive = params
+	}
+}
+
+// WithRetryPolicy overrides the DefaultRetryPolicy.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(o *options) {
+		o.retryPolicy = &policy
+	}
+}
+
+// WithoutRetry disables the transparent retries of failed RPCs.
+func WithoutRetry() Option {
+	return func(o *options) {
+		o.retryPolicy = nil
+	}
+}
+
+// WithConnectTimeout sets how long NewClient waits for the
+// connection to become ready before proceeding in background.
+func WithConnectTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.connectTimeout = d
+	}
+}
+
+// WithMinConnectTimeout sets the minimum time given to every connection
+// attempt, defaults to the 20 seconds of gRPC. Lowering it fails faster
+// on a black-holed address, but may abort slow TLS handshakes.
+func WithMinConnectTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.minConnect = d
+	}
+}
+
+// WithDialOptions appends raw gRPC dial options. They are applied
+// after the SDK options and take precedence over them.
+func WithDialOptions(opts ...grpc.DialOption) Option {
+	return func(o *options) {
+		o.dialOpts = append(o.dialOpts, opts...)
+	}
+}
+
+func (o *options) dialOptions() ([]grpc.DialOption, error) {
+	creds := o.creds
+	if o.mtls != nil {
+		rc, err := newReloadingCredentials(*o.mtls, o.logger)
+		if err != nil {
+			return nil, err
+		}
+		creds = rc
+	}
+	opts := []grpc.DialOption{
+		grpc.WithTransportCredentials(creds),
+		grpc.WithKeepaliveParams(o.keepalive),
+	}
+	if o.perRPC != nil {
+		perRPC := o.perRPC
+		if o.insecurePerRPC {
+			perRPC = insecurePerRPC{perRPC}
+		}
+		opts = append(opts, grpc.WithPerRPCCredentials(perRPC))
+	}
+	if _, nop := o.metrics.(nopMetrics); !nop {
+		rm := rpcMetrics{o.metrics}
+		opts = append(opts,
+			grpc.WithChainUnaryInterceptor(rm.unaryInterceptor),
+			grpc.WithChainStreamInterceptor(rm.streamInterceptor),
+		)
+	}
+	if o.tracerProvider != nil || o.propagator != nil {
+		opts = append(opts, grpc.WithChainUnaryInterceptor(propagatingInterceptor(o.propagatorOrGlobal())))
+	}
+	if o.breaker != nil || len(o.methodBreakers) > 0 {
+		b := newBreakers(o.breaker, o.methodBreakers, o.logger)
+		opts = append(opts, grpc.WithChainUnaryInterceptor(b.unaryInterceptor))
+	}
+	if o.authority != "" {
+		opts = append(opts, grpc.WithAuthority(o.authority))
+	}
+	if o.minConnect > 0 {
+		opts = append(opts, grpc.WithConnectParams(grpc.ConnectParams{
+			Backoff:           backoff.DefaultConfig,
+			MinConnectTimeout: o.minConnect,
+		}))
+	}
+	if o.retryPolicy != nil {
+		cfg, err := o.retryPolicy.serviceConfig()
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, grpc.WithDefaultServiceConfig(cfg))
+	}
+	return append(opts, o.dialOpts...), nil
+}
+
+// serviceConfig renders the policy as a gRPC JSON service config.
+func (p RetryPolicy) serviceConfig() (string, error) {
+	if p.MaxAttempts < 2 {
+		return "", fmt.Errorf("identity: retry policy requires at least 2 attempts, got %d", p.MaxAttempts)
+	}
+	if len(p.RetryableStatusCodes) == 0 {
+		return "", fmt.Errorf("identity: retry policy requires at least one retryable status code")
+	}
+	cfg := map[string]any{
+		"methodConfig": []any{
+			map[string]any{
+				"name": []any{map[string]any{"service": ""}},
+				"retryPolicy": map[string]any{
+					"maxAttempts":          p.MaxAttempts,
+					"initialBackoff":       durationString(p.InitialBackoff),
+					"maxBackoff":           durationString(p.MaxBackoff),
+					"backoffMultiplier":    p.BackoffMultiplier,
+					"retryableStatusCodes": p.RetryableStatusCodes,
+				},
+			},
+		},
+	}
+	b, err := json.Marshal(cfg)
+	return string(b), err
+}
+
+// durationString formats d as a protobuf JSON duration, e.g. "0.1s".
+func durationString(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', -1, 64) + "s"
+}
@@ -0,0 +1,290 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/kodeart/identity-sdk-go"
+	pb "github.com/kodeart/identity-sdk-go/proto/v1"
+)
+
+// Input is what a decision is made about.
+//
+// It is exposed to the conditions as a document with the keys
+// "principal" (user_id, email, tenant_id, tenant_slug, roles, permissions,
+// scopes, session_id, metadata), "tenant" (id, slug, name, settings),
+// "action" and "resource" holding the Attributes.
+type Input struct {
+	Principal  *identity.Principal
+	Tenant     *pb.Tenant
+	Action     string
+	Attributes map[string]any
+}
+
+// Decision is the outcome of an evaluation.
+type Decision struct {
+	Allowed bool
+	// Policy is the name of the deciding policy, empty when nothing applied.
+	Policy string
+	Reason string
+	// Trace lists every policy matching the action, in evaluation order.
+	Trace []PolicyTrace
+}
+
+// PolicyTrace explains how a policy was evaluated.
+type PolicyTrace struct {
+	Policy  string
+	Effect  Effect
+	Applied bool
+	// Indeterminate is set when a condition could not be evaluated,
+	// the policy then applies only if it denies.
+	Indeterminate bool
+	Conditions    []ConditionTrace
+}
+
+// ConditionTrace shows the compared values of a condition.
+type ConditionTrace struct {
+	Condition Condition
+	Actual    any
+	Expected  any
+	Holds     bool
+	// Missing is set when the attribute, or the one at Ref, is missing.
+	Missing bool
+}
+
+// Evaluate decides on the input. Deny policies take precedence over
+// allow policies, and the request is denied when no policy applies.
+//
+// A deny policy fails closed: it applies when its conditions hold or
+// cannot be evaluated because an attribute is missing, and its trace
+// is marked Indeterminate.
+func (e *Engine) Evaluate(in Input) *Decision {
+	doc := in.document()
+	d := &Decision{}
+	var allowedBy string
+	for _, p := range e.policies {
+		if !p.matches(in.Action) {
+			continue
+		}
+		pt := PolicyTrace{Policy: p.Name, Effect: p.Effect, Applied: true}
+		for _, c := range p.When {
+			ct := c.evaluate(doc)
+			pt.Conditions = append(pt.Conditions, ct)
+			switch {
+			case ct.Missing:
+				pt.Indeterminate = true
+			case !ct.Holds:
+				pt.Applied = false
+			}
+		}
+		if pt.Indeterminate && p.Effect == Allow {
+			pt.Applied = false
+		}
+		d.Trace = append(d.Trace, pt)
+		if !pt.Applied {
+			continue
+		}
+		if p.Effect == Deny && d.Policy == "" {
+			d.Policy = p.Name
+			d.Reason = fmt.Sprintf("denied by policy %q", p.Name)
+			if pt.Indeterminate {
+				d.Reason += ", an attribute is missing"
+			}
+		}
+		if p.Effect == Allow && allowedBy == "" {
+			allowedBy = p.Name
+		}
+	}
+	switch {
+	case d.Policy != "":
+	case allowedBy != "":
+		d.Allowed = true
+		d.Policy = allowedBy
+		d.Reason = fmt.Sprintf("allowed by policy %q", allowedBy)
+	default:
+		d.Reason = fmt.Sprintf("no policy allows %q", in.Action)
+	}
+	return d
+}
+
+// Explain renders the decision and its trace for debugging.
+func (d *Decision) Explain() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: %s\n", map[bool]string{true: "ALLOW", false: "DENY"}[d.Allowed], d.Reason)
+	for _, pt := range d.Trace {
+		fmt.Fprintf(&b, "  %s policy %q applied=%t", pt.Effect, pt.Policy, pt.Applied)
+		if pt.Indeterminate {
+			b.WriteString(" indeterminate")
+		}
+		b.WriteByte('\n')
+		for _, ct := range pt.Conditions {
+			c := ct.Condition
+			operand := c.Ref
+			if operand == "" {
+				operand = fmt.Sprintf("%v", c.Value)
+			}
+			mark := map[bool]string{true: "x", false: " "}[ct.Holds]
+			if ct.Missing {
+				mark = "?"
+			}
+			fmt.Fprintf(&b, "    [%s] %s %s %s (actual=%v expected=%v)\n",
+				mark, c.Attr, c.Op, operand, ct.Actual, ct.Expected)
+		}
+	}
+	return b.String()
+}
+
+func (in Input) document() map[string]any {
+	doc := map[string]any{
+		"action":   in.Action,
+		"resource": normalize(in.Attributes),
+	}
+	if p := in.Principal; p != nil {
+		doc["principal"] = map[string]any{
+			"user_id":     p.User.GetId(),
+			"email":       p.User.GetEmail(),
+			"tenant_id":   p.TenantID,
+			"tenant_slug": p.TenantSlug,
+			"roles":       normalize(p.User.GetRoles()),
+			"permissions": normalize(p.User.GetPermissions()),
+			"scopes":      normalize(p.Scopes),
+			"session_id":  p.SessionID,
+			"metadata":    p.User.GetMetadata().AsMap(),
+		}
+	}
+	if t := in.Tenant; t != nil {
+		doc["tenant"] = map[string]any{
+			"id":       t.GetId(),
+			"slug":     t.GetSlug(),
+			"name":     t.GetName(),
+			"settings": t.GetSettings().AsMap(),
+		}
+	}
+	return doc
+}
+
+// normalize converts the value to the JSON data model, so
+// typed Go values compare like the values of the policy file.
+func normalize(v any) any {
+	if v == nil {
+		return nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var out any
+	if err := json.Unmarshal(b, &out); err != nil {
+		return v
+	}
+	return out
+}
+
+// lookup resolves a dotted path in the document.
+func lookup(doc map[string]any, path string) (any, bool) {
+	var cur any = doc
+	for _, key := range strings.Split(path, ".") {
+		m, ok := cur.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if cur, ok = m[key]; !ok {
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+func (c Condition) evaluate(doc map[string]any) ConditionTrace {
+	actual, found := lookup(doc, c.Attr)
+	ct := ConditionTrace{Condition: c, Actual: actual}
+
+	if c.Op == OpExists {
+		want := true
+		if b, ok := c.Value.(bool); ok {
+			want = b
+		}
+		ct.Expected = want
+		ct.Holds = found == want
+		return ct
+	}
+
+	expected := normalize(c.Value)
+	refFound := true
+	if c.Ref != "" {
+		expected, refFound = lookup(doc, c.Ref)
+	}
+	ct.Expected = expected
+	if !found || !refFound {
+		// Nothing holds on a missing attribute, not even "ne" or
+		// "not_in", see Evaluate for how the policy is then decided.
+		ct.Missing = true
+		return ct
+	}
+
+	switch c.Op {
+	case OpEq:
+		ct.Holds = equal(actual, expected)
+	case OpNe:
+		ct.Holds = !equal(actual, expected)
+	case OpIn:
+		ct.Holds = contains(expected, actual)
+	case OpNotIn:
+		ct.Holds = !contains(expected, actual)
+	case OpContains:
+		ct.Holds = contains(actual, expected)
+	case OpPrefix:
+		s, ok1 := actual.(string)
+		p, ok2 := expected.(string)
+		ct.Holds = ok1 && ok2 && strings.HasPrefix(s, p)
+	case OpGt, OpGte, OpLt, OpLte:
+		a, ok1 := number(actual)
+		b, ok2 := number(expected)
+		if ok1 && ok2 {
+			switch c.Op {
+			case OpGt:
+				ct.Holds = a > b
+			case OpGte:
+				ct.Holds = a >= b
+			case OpLt:
+				ct.Holds = a < b
+			case OpLte:
+				ct.Holds = a <= b
+			}
+		}
+	}
+	return ct
+}
+
+func equal(a, b any) bool {
+	if x, ok := number(a); ok {
+		y, ok := number(b)
+		return ok && x == y
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// contains reports whether the list holds the value.
+func contains(list, v any) bool {
+	items, ok := list.([]any)
+	if !ok {
+		return false
+	}
+	return slices.ContainsFunc(items, func(item any) bool {
+		return equal(item, v)
+	})
+}
+
+func number(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
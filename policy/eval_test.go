@@ -0,0 +1,210 @@
+package policy
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kodeart/identity-sdk-go"
+	pb "github.com/kodeart/identity-sdk-go/proto/v1"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func testInput(t *testing.T) Input {
+	t.Helper()
+	metadata, err := structpb.NewStruct(map[string]any{"plan": "pro", "seats": 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Input{
+		Principal: &identity.Principal{
+			User: &pb.User{
+				Id:          "u1",
+				Email:       "ada@example.com",
+				Roles:       []string{"editor"},
+				Permissions: []string{"docs:read", "docs:edit"},
+				Metadata:    metadata,
+			},
+			TenantID: "t1",
+			Scopes:   []string{"openid"},
+		},
+		Tenant: &pb.Tenant{Id: "t1", Slug: "acme", Name: "Acme"},
+		Action: "docs:edit",
+		Attributes: map[string]any{
+			"tenant_id": "t1",
+			"owner":     "u1",
+			"size":      int64(10),
+			"tags":      []string{"draft", "internal"},
+		},
+	}
+}
+
+func TestConditionOperators(t *testing.T) {
+	tests := []struct {
+		name string
+		cond Condition
+		want bool
+	}{
+		{"eq", Condition{Attr: "resource.owner", Op: OpEq, Value: "u1"}, true},
+		{"eq mismatch", Condition{Attr: "resource.owner", Op: OpEq, Value: "u2"}, false},
+		{"ne", Condition{Attr: "resource.owner", Op: OpNe, Value: "u2"}, true},
+		{"ne equal", Condition{Attr: "resource.owner", Op: OpNe, Value: "u1"}, false},
+		{"in", Condition{Attr: "principal.tenant_id", Op: OpIn, Value: []any{"t0", "t1"}}, true},
+		{"in missing value", Condition{Attr: "principal.tenant_id", Op: OpIn, Value: []any{"t0"}}, false},
+		{"not_in", Condition{Attr: "principal.tenant_id", Op: OpNotIn, Value: []any{"t0"}}, true},
+		{"not_in listed", Condition{Attr: "principal.tenant_id", Op: OpNotIn, Value: []any{"t1"}}, false},
+		{"contains", Condition{Attr: "principal.roles", Op: OpContains, Value: "editor"}, true},
+		{"contains absent", Condition{Attr: "principal.roles", Op: OpContains, Value: "admin"}, false},
+		{"contains on a string", Condition{Attr: "principal.email", Op: OpContains, Value: "ada"}, false},
+		{"prefix", Condition{Attr: "principal.email", Op: OpPrefix, Value: "ada@"}, true},
+		{"prefix mismatch", Condition{Attr: "principal.email", Op: OpPrefix, Value: "bob@"}, false},
+		{"prefix on a number", Condition{Attr: "resource.size", Op: OpPrefix, Value: "1"}, false},
+		{"exists", Condition{Attr: "resource.owner", Op: OpExists}, true},
+		{"exists false", Condition{Attr: "resource.missing", Op: OpExists, Value: false}, true},
+		{"exists on missing", Condition{Attr: "resource.missing", Op: OpExists}, false},
+		{"gt", Condition{Attr: "resource.size", Op: OpGt, Value: 9}, true},
+		{"gt equal", Condition{Attr: "resource.size", Op: OpGt, Value: 10}, false},
+		{"gte", Condition{Attr: "resource.size", Op: OpGte, Value: 10}, true},
+		{"lt", Condition{Attr: "resource.size", Op: OpLt, Value: 10.5}, true},
+		{"lte", Condition{Attr: "resource.size", Op: OpLte, Value: 9}, false},
+		{"gt on a string", Condition{Attr: "resource.owner", Op: OpGt, Value: 1}, false},
+		{"number normalization", Condition{Attr: "principal.metadata.seats", Op: OpEq, Value: int32(5)}, true},
+		{"typed slice normalization", Condition{Attr: "resource.tags", Op: OpContains, Value: "draft"}, true},
+		{"nested path", Condition{Attr: "principal.metadata.plan", Op: OpEq, Value: "pro"}, true},
+		{"ref", Condition{Attr: "resource.tenant_id", Op: OpEq, Ref: "principal.tenant_id"}, true},
+		{"ref mismatch", Condition{Attr: "resource.owner", Op: OpEq, Ref: "tenant.id"}, false},
+	}
+	doc := testInput(t).document()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ct := tt.cond.evaluate(doc)
+			if ct.Holds != tt.want || ct.Missing {
+				t.Errorf("Holds = %t, Missing = %t, want %t (actual=%v expected=%v)", ct.Holds, ct.Missing, tt.want, ct.Actual, ct.Expected)
+			}
+		})
+	}
+}
+
+func TestConditionMissingAttribute(t *testing.T) {
+	doc := testInput(t).document()
+	for _, c := range []Condition{
+		{Attr: "resource.missing", Op: OpEq, Value: "x"},
+		{Attr: "resource.missing", Op: OpNe, Value: "x"},
+		{Attr: "resource.missing", Op: OpNotIn, Value: []any{"x"}},
+		{Attr: "resource.owner", Op: OpNe, Ref: "principal.missing"},
+		{Attr: "principal.metadata.plan.tier", Op: OpNe, Value: "x"},
+	} {
+		if ct := c.evaluate(doc); ct.Holds || !ct.Missing {
+			t.Errorf("%s %s: Holds = %t, Missing = %t, want a missing attribute", c.Attr, c.Op, ct.Holds, ct.Missing)
+		}
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	allowAll := Policy{Name: "allow-docs", Effect: Allow, Actions: []string{"docs:*"}}
+	denyOtherTenant := Policy{
+		Name: "deny-other-tenant", Effect: Deny, Actions: []string{"docs:edit"},
+		When: []Condition{{Attr: "resource.tenant_id", Op: OpNe, Ref: "principal.tenant_id"}},
+	}
+	allowOwner := Policy{
+		Name: "allow-owner", Effect: Allow, Actions: []string{"docs:edit"},
+		When: []Condition{{Attr: "resource.owner", Op: OpEq, Ref: "principal.user_id"}},
+	}
+	tests := []struct {
+		name     string
+		policies []Policy
+		input    func(*Input)
+		allowed  bool
+		policy   string
+	}{
+		{"no policy", nil, nil, false, ""},
+		{"other action", []Policy{{Name: "p", Effect: Allow, Actions: []string{"users:*"}}}, nil, false, ""},
+		{"allow", []Policy{allowAll}, nil, true, "allow-docs"},
+		{"deny not applying", []Policy{denyOtherTenant, allowAll}, nil, true, "allow-docs"},
+		{"deny takes precedence", []Policy{allowAll, denyOtherTenant}, func(in *Input) {
+			in.Attributes["tenant_id"] = "t2"
+		}, false, "deny-other-tenant"},
+		{"deny fails closed on a missing attribute", []Policy{allowAll, denyOtherTenant}, func(in *Input) {
+			delete(in.Attributes, "tenant_id")
+		}, false, "deny-other-tenant"},
+		{"deny fails closed without principal", []Policy{allowAll, denyOtherTenant}, func(in *Input) {
+			in.Principal = nil
+		}, false, "deny-other-tenant"},
+		{"allow needs its attributes", []Policy{allowOwner}, func(in *Input) {
+			in.Principal = nil
+		}, false, ""},
+		{"deny with a false condition", []Policy{allowAll, {
+			Name: "deny-draft-of-other", Effect: Deny, Actions: []string{"docs:edit"},
+			When: []Condition{
+				{Attr: "resource.missing", Op: OpEq, Value: "x"},
+				{Attr: "resource.owner", Op: OpEq, Value: "u2"},
+			},
+		}}, nil, true, "allow-docs"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := New(tt.policies...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			in := testInput(t)
+			if tt.input != nil {
+				tt.input(&in)
+			}
+			d := e.Evaluate(in)
+			if d.Allowed != tt.allowed || d.Policy != tt.policy {
+				t.Errorf("got allowed=%t policy=%q, want allowed=%t policy=%q\n%s", d.Allowed, d.Policy, tt.allowed, tt.policy, d.Explain())
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	e, err := Parse([]byte(`{"policies": [{
+		"name": "big-docs", "effect": "allow", "actions": ["docs:edit"],
+		"when": [{"attr": "resource.size", "op": "gte", "value": 10}]
+	}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := e.Evaluate(testInput(t)); !d.Allowed {
+		t.Errorf("json.Number value not normalized:\n%s", d.Explain())
+	}
+
+	for _, doc := range []string{
+		`{"policies": [{"name": "p", "effect": "maybe", "actions": ["a"]}]}`,
+		`{"policies": [{"name": "p", "effect": "allow"}]}`,
+		`{"policies": [{"name": "p", "effect": "allow", "actions": ["a"], "when": [{"attr": "x", "op": "like", "value": 1}]}]}`,
+		`{"policies": [{"name": "p", "effect": "allow", "actions": ["a"], "when": [{"attr": "x", "op": "eq"}]}]}`,
+		`{"policies": [{"name": "p", "effect": "allow", "actions": ["a"], "unknown": true}]}`,
+	} {
+		if _, err := Parse([]byte(doc)); err == nil {
+			t.Errorf("Parse(%s) accepted an invalid document", doc)
+		}
+	}
+}
+
+func TestExplain(t *testing.T) {
+	e, err := New(
+		Policy{Name: "allow-docs", Effect: Allow, Actions: []string{"docs:*"}},
+		Policy{
+			Name: "deny-other-tenant", Effect: Deny, Actions: []string{"docs:edit"},
+			When: []Condition{{Attr: "resource.tenant_id", Op: OpNe, Ref: "principal.tenant_id"}},
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	in := testInput(t)
+	delete(in.Attributes, "tenant_id")
+	got := e.Evaluate(in).Explain()
+	for _, want := range []string{
+		`DENY: denied by policy "deny-other-tenant", an attribute is missing`,
+		`allow policy "allow-docs" applied=true`,
+		`deny policy "deny-other-tenant" applied=true indeterminate`,
+		`[?] resource.tenant_id ne principal.tenant_id (actual=<nil> expected=t1)`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Explain misses %q:\n%s", want, got)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package policy
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/kodeart/go-problem/v2"
+	"github.com/kodeart/identity-sdk-go"
+	pb "github.com/kodeart/identity-sdk-go/proto/v1"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Option configures the Middleware and the interceptor.
+type Option func(*config)
+
+type config struct {
+	attributes    func(r *http.Request) map[string]any
+	rpcAttributes func(ctx context.Context, req any) map[string]any
+	tenant        func(ctx context.Context, p *identity.Principal) (*pb.Tenant, error)
+	explain       bool
+	logger        *zerolog.Logger
+}
+
+// WithAttributes extracts the resource attributes of an HTTP request,
+// e.g. the tenant id of the document being edited.
+func WithAttributes(fn func(r *http.Request) map[string]any) Option {
+	return func(c *config) {
+		c.attributes = fn
+	}
+}
+
+// WithRPCAttributes extracts the resource attributes of a gRPC request message.
+func WithRPCAttributes(fn func(ctx context.Context, req any) map[string]any) Option {
+	return func(c *config) {
+		c.rpcAttributes = fn
+	}
+}
+
+// WithTenant resolves the tenant of the principal, making
+// "tenant.settings" available to the conditions.
+func WithTenant(fn func(ctx context.Context, p *identity.Principal) (*pb.Tenant, error)) Option {
+	return func(c *config) {
+		c.tenant = fn
+	}
+}
+
+// WithExplain adds the decision trace to the denied responses.
+// It reveals the policies, so use it only while debugging.
+func WithExplain() Option {
+	return func(c *config) {
+		c.explain = true
+	}
+}
+
+// WithLogger sets the logger of the decisions, defaults to the global zerolog logger.
+// Decisions are logged at debug level with their explanation.
+func WithLogger(l *zerolog.Logger) Option {
+	return func(c *config) {
+		c.logger = l
+	}
+}
+
+func newConfig(opts []Option) *config {
+	c := &config{logger: &log.Logger}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// decide evaluates the action for the principal of the context.
+func (c *config) decide(ctx context.Context, e *Engine, action string, attrs map[string]any) (*Decision, error) {
+	in := Input{Action: action, Attributes: attrs}
+	if p, ok := identity.PrincipalFromContext(ctx); ok {
+		in.Principal = p
+		if c.tenant != nil {
+			t, err := c.tenant(ctx, p)
+			if err != nil {
+				return nil, err
+			}
+			in.Tenant = t
+		}
+	}
+	d := e.Evaluate(in)
+	if c.logger.Debug().Enabled() {
+		c.logger.Debug().Str("action", action).Bool("allowed", d.Allowed).Msg(d.Explain())
+	}
+	return d, nil
+}
+
+// Middleware lets through the requests the engine allows for the action.
+// It must be mounted after IdentityAuth; denied requests get a 403 problem.
+func Middleware(e *Engine, action string, opts ...Option) func(http.Handler) http.Handler {
+	cfg := newConfig(opts)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var attrs map[string]any
+			if cfg.attributes != nil {
+				attrs = cfg.attributes(r)
+			}
+			d, err := cfg.decide(r.Context(), e, action, attrs)
+			if err != nil {
+				// The error of the tenant resolver may carry internal details.
+				cfg.logger.Error().Err(err).Str("action", action).Msg("policy evaluation failed")
+				identity.AsProblem(r, status.Error(codes.Internal, "The policy evaluation failed")).JSON(w)
+				return
+			}
+			if !d.Allowed {
+				p := problem.New().
+					WithDetail(d.Reason).
+					WithTitle("Identity Forbidden").
+					WithStatus(http.StatusForbidden).
+					WithInstance(r.URL.String())
+				if cfg.explain {
+					p.WithExtension("explain", d.Explain())
+				}
+				p.JSON(w)
+
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// UnaryServerInterceptor lets through the calls the engine allows. The action
+// is the full method name, e.g. "/identity.v1.IdentityService/GetUser".
+// It must be chained after the authentication interceptor.
+func UnaryServerInterceptor(e *Engine, opts ...Option) grpc.UnaryServerInterceptor {
+	cfg := newConfig(opts)
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		var attrs map[string]any
+		if cfg.rpcAttributes != nil {
+			attrs = cfg.rpcAttributes(ctx, req)
+		}
+		d, err := cfg.decide(ctx, e, info.FullMethod, attrs)
+		if err != nil {
+			cfg.logger.Error().Err(err).Str("action", info.FullMethod).Msg("policy evaluation failed")
+			return nil, status.Error(codes.Internal, "policy evaluation failed")
+		}
+		if !d.Allowed {
+			msg := d.Reason
+			if cfg.explain {
+				msg = d.Explain()
+			}
+			return nil, status.Error(codes.PermissionDenied, msg)
+		}
+		return handler(ctx, req)
+	}
+}
@@ -0,0 +1,70 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kodeart/identity-sdk-go"
+	pb "github.com/kodeart/identity-sdk-go/proto/v1"
+	"github.com/rs/zerolog"
+)
+
+func TestMiddleware(t *testing.T) {
+	e, err := New(Policy{
+		Name: "pro-only", Effect: Allow, Actions: []string{"docs:edit"},
+		When: []Condition{{Attr: "tenant.settings.plan", Op: OpEq, Value: "pro"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	nop := zerolog.Nop()
+	tenants := map[string]*pb.Tenant{"t1": {Id: "t1"}}
+	tenants["t1"].Settings, _ = identity.EncodeSettings(map[string]any{"plan": "pro"})
+	resolve := WithTenant(func(_ context.Context, p *identity.Principal) (*pb.Tenant, error) {
+		if t, ok := tenants[p.TenantID]; ok {
+			return t, nil
+		}
+		return nil, errors.New("tenant store: dial tcp 10.0.0.7:5432: connection refused")
+	})
+	h := Middleware(e, "docs:edit", resolve, WithLogger(&nop))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	tests := []struct {
+		name      string
+		tenantID  string
+		status    int
+		notInBody string
+	}{
+		{"allowed", "t1", http.StatusNoContent, ""},
+		{"tenant resolver failure", "t2", http.StatusInternalServerError, "10.0.0.7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/docs/1", nil)
+			p := &identity.Principal{User: &pb.User{Id: "u1"}, TenantID: tt.tenantID}
+			r = r.WithContext(identity.WithPrincipal(r.Context(), p))
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			if tt.notInBody != "" && strings.Contains(w.Body.String(), tt.notInBody) {
+				t.Errorf("the response leaks the resolver error: %s", w.Body)
+			}
+		})
+	}
+
+	tenants["t1"].Settings, _ = identity.EncodeSettings(map[string]any{"plan": "free"})
+	r := httptest.NewRequest(http.MethodPost, "/docs/1", nil)
+	r = r.WithContext(identity.WithPrincipal(r.Context(), &identity.Principal{User: &pb.User{Id: "u1"}, TenantID: "t1"}))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("status = %d, want 403: %s", w.Code, w.Body)
+	}
+}
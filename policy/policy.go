@@ -0,0 +1,183 @@
+// Package policy implements attribute-based access control over the
+// identity.Principal, the request attributes and the tenant settings.
+//
+// Policies are declared in JSON, YAML is not supported:
+//
+//	{
+//	  "policies": [{
+//	    "name": "edit-own-tenant",
+//	    "effect": "allow",
+//	    "actions": ["documents:edit"],
+//	    "when": [
+//	      {"attr": "resource.tenant_id", "op": "eq", "ref": "principal.tenant_id"},
+//	      {"attr": "principal.metadata.plan", "op": "eq", "value": "pro"}
+//	    ]
+//	  }]
+//	}
+//
+// A policy applies when one of its actions matches and all of its
+// conditions hold. A request is allowed when at least one "allow" policy
+// applies and no "deny" policy does; everything else is denied.
+//
+// A condition on a missing attribute, e.g. on the principal of an
+// anonymous request, cannot be evaluated, whatever its operator: an
+// allow policy then does not apply and a deny policy applies. Use
+// "exists" to test for the attribute itself.
+package policy
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Effect of a policy that applies.
+type Effect string
+
+const (
+	Allow Effect = "allow"
+	Deny  Effect = "deny"
+)
+
+// Operators usable in a Condition.
+const (
+	OpEq       = "eq"
+	OpNe       = "ne"
+	OpIn       = "in"
+	OpNotIn    = "not_in"
+	OpContains = "contains"
+	OpPrefix   = "prefix"
+	OpExists   = "exists"
+	OpGt       = "gt"
+	OpGte      = "gte"
+	OpLt       = "lt"
+	OpLte      = "lte"
+)
+
+// Policy is a single rule of the Engine.
+type Policy struct {
+	Name   string `json:"name"`
+	Effect Effect `json:"effect"`
+	// Actions the policy applies to. "*" matches every action and
+	// a trailing "*" matches a prefix, e.g. "documents:*".
+	Actions []string    `json:"actions"`
+	When    []Condition `json:"when,omitempty"`
+}
+
+// Condition compares the attribute at Attr to either a literal
+// Value or to the attribute at Ref. Attributes are dotted paths
+// into the Input document, e.g. "principal.roles".
+type Condition struct {
+	Attr  string `json:"attr"`
+	Op    string `json:"op"`
+	Value any    `json:"value,omitempty"`
+	Ref   string `json:"ref,omitempty"`
+}
+
+// Document is the declarative format of a policy set.
+type Document struct {
+	Policies []Policy `json:"policies"`
+}
+
+// Engine evaluates a validated set of policies.
+type Engine struct {
+	policies []Policy
+}
+
+// New validates the policies and returns their Engine.
+func New(policies ...Policy) (*Engine, error) {
+	names := make(map[string]bool, len(policies))
+	for i, p := range policies {
+		if p.Name == "" {
+			return nil, fmt.Errorf("policy: policy #%d has no name", i)
+		}
+		if names[p.Name] {
+			return nil, fmt.Errorf("policy: duplicate policy %q", p.Name)
+		}
+		names[p.Name] = true
+		if p.Effect != Allow && p.Effect != Deny {
+			return nil, fmt.Errorf("policy %q: unknown effect %q", p.Name, p.Effect)
+		}
+		if len(p.Actions) == 0 {
+			return nil, fmt.Errorf("policy %q: no actions", p.Name)
+		}
+		for j, c := range p.When {
+			if err := c.validate(); err != nil {
+				return nil, fmt.Errorf("policy %q: condition #%d: %w", p.Name, j, err)
+			}
+		}
+	}
+	return &Engine{policies: policies}, nil
+}
+
+// Parse reads a JSON policy Document.
+func Parse(data []byte) (*Engine, error) {
+	var doc Document
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("policy: %w", err)
+	}
+	return New(doc.Policies...)
+}
+
+// Load reads a JSON policy Document from r.
+func Load(r io.Reader) (*Engine, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// LoadFile reads a JSON policy Document from the file.
+func LoadFile(path string) (*Engine, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+func (c Condition) validate() error {
+	if c.Attr == "" {
+		return errors.New("missing attr")
+	}
+	switch c.Op {
+	case OpExists:
+		if c.Ref != "" {
+			return errors.New(`"exists" takes no ref`)
+		}
+		if c.Value != nil {
+			if _, ok := c.Value.(bool); !ok {
+				return errors.New(`"exists" value must be a boolean`)
+			}
+		}
+		return nil
+	case OpEq, OpNe, OpIn, OpNotIn, OpContains, OpPrefix, OpGt, OpGte, OpLt, OpLte:
+	default:
+		return fmt.Errorf("unknown op %q", c.Op)
+	}
+	if (c.Value == nil) == (c.Ref == "") {
+		return errors.New("exactly one of value or ref is required")
+	}
+	return nil
+}
+
+// matches reports whether the policy applies to the action.
+func (p Policy) matches(action string) bool {
+	for _, a := range p.Actions {
+		if a == "*" || a == action {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(a, "*"); ok && strings.HasPrefix(action, prefix) {
+			return true
+		}
+	}
+	return false
+}
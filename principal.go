@@ -0,0 +1,65 @@
+package identity
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	pb "github.com/kodeart/identity-sdk-go/proto/v1"
+)
+
+const principalContextKey contextKey = "identity-principal"
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	User       *pb.User
+	TenantID   string
+	TenantSlug string
+	SessionID  string
+	Scopes     []string
+	ExpiresAt  time.Time
+	// Token is the raw bearer token, e.g. to call other services on behalf of the user.
+	Token string
+}
+
+// NewPrincipal builds the principal of a validated session token.
+func NewPrincipal(token string, session *SessionResult) *Principal {
+	return &Principal{
+		User:       session.User,
+		TenantID:   session.User.GetTenantId(),
+		TenantSlug: session.User.GetTenantSlug(),
+		SessionID:  session.SessionID,
+		Scopes:     session.Scopes,
+		ExpiresAt:  session.ExpiresAt,
+		Token:      token,
+	}
+}
+
+// HasScope reports whether the session was granted the scope.
+func (p *Principal) HasScope(scope string) bool {
+	return slices.Contains(p.Scopes, scope)
+}
+
+// HasRole reports whether the user was granted the role.
+func (p *Principal) HasRole(role string) bool {
+	return slices.Contains(p.User.GetRoles(), role)
+}
+
+// HasPermission reports whether the user was granted the permission.
+func (p *Principal) HasPermission(permission string) bool {
+	return slices.Contains(p.User.GetPermissions(), permission)
+}
+
+// WithPrincipal returns a copy of the context carrying the principal.
+// The principal user is also available through UserFromContext.
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	ctx = context.WithValue(ctx, principalContextKey, p)
+	return WithUser(ctx, p.User)
+}
+
+// PrincipalFromContext retrieves the principal from a request context.
+// It reports false if the request is not authenticated.
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalContextKey).(*Principal)
+	return p, ok && p != nil
+}
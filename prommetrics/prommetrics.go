@@ -0,0 +1,106 @@
+// Package prommetrics implements identity.Metrics with Prometheus collectors.
+//
+//	m, err := prommetrics.New(prometheus.DefaultRegisterer)
+//	client, err := identity.NewClient(target, identity.WithMetrics(m), identity.WithSessionCache(identity.CacheConfig{}))
+//	auth := middleware.IdentityAuthWithOptions(client, middleware.WithMetrics(m))
+//
+// The cache hit ratio is given by
+//
+//	sum(rate(identity_session_cache_lookups_total{result="hit"}[5m]))
+//	  / sum(rate(identity_session_cache_lookups_total[5m]))
+package prommetrics
+
+import (
+	"time"
+
+	"github.com/kodeart/identity-sdk-go"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc/codes"
+)
+
+// Metrics holds the collectors, it is safe for concurrent use.
+type Metrics struct {
+	rpcs        *prometheus.CounterVec
+	rpcDuration *prometheus.HistogramVec
+	cache       *prometheus.CounterVec
+	auth        *prometheus.CounterVec
+}
+
+var _ identity.Metrics = (*Metrics)(nil)
+
+// Option configures the Metrics.
+type Option func(*config)
+
+type config struct {
+	namespace string
+	buckets   []float64
+}
+
+// WithNamespace prefixes the metric names, defaults to "identity".
+func WithNamespace(namespace string) Option {
+	return func(c *config) {
+		c.namespace = namespace
+	}
+}
+
+// WithBuckets sets the buckets of the RPC duration histogram,
+// defaults to prometheus.DefBuckets.
+func WithBuckets(buckets ...float64) Option {
+	return func(c *config) {
+		c.buckets = buckets
+	}
+}
+
+// New registers the collectors with the registerer.
+func New(reg prometheus.Registerer, opts ...Option) (*Metrics, error) {
+	cfg := &config{namespace: "identity", buckets: prometheus.DefBuckets}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	m := &Metrics{
+		rpcs: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: cfg.namespace,
+			Subsystem: "client",
+			Name:      "rpc_total",
+			Help:      "Calls of the Identity Service by method and status code.",
+		}, []string{"method", "code"}),
+		rpcDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: cfg.namespace,
+			Subsystem: "client",
+			Name:      "rpc_duration_seconds",
+			Help:      "Duration of the calls of the Identity Service by method and status code.",
+			Buckets:   cfg.buckets,
+		}, []string{"method", "code"}),
+		cache: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: cfg.namespace,
+			Subsystem: "session_cache",
+			Name:      "lookups_total",
+			Help:      "Session cache lookups by result (hit, miss, stale).",
+		}, []string{"result"}),
+		auth: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: cfg.namespace,
+			Subsystem: "middleware",
+			Name:      "requests_total",
+			Help:      "Requests seen by the authentication middleware by outcome.",
+		}, []string{"outcome"}),
+	}
+	for _, c := range []prometheus.Collector{m.rpcs, m.rpcDuration, m.cache, m.auth} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+func (m *Metrics) ObserveRPC(method string, code codes.Code, duration time.Duration) {
+	m.rpcs.WithLabelValues(method, code.String()).Inc()
+	m.rpcDuration.WithLabelValues(method, code.String()).Observe(duration.Seconds())
+}
+
+func (m *Metrics) ObserveCache(result identity.CacheResult) {
+	m.cache.WithLabelValues(string(result)).Inc()
+}
+
+func (m *Metrics) ObserveAuth(outcome identity.AuthOutcome) {
+	m.auth.WithLabelValues(string(outcome)).Inc()
+}
@@ -9,6 +9,7 @@ package identityv1
 import (
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	fieldmaskpb "google.golang.org/protobuf/types/known/fieldmaskpb"
 	structpb "google.golang.org/protobuf/types/known/structpb"
 	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 	reflect "reflect"
@@ -282,7 +283,14 @@ type ValidateSessionResponse struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
 	Valid bool                   `protobuf:"varint,1,opt,name=valid,proto3" json:"valid,omitempty"`
 	// Returns the user context if valid
-	User          *User `protobuf:"bytes,2,opt,name=user,proto3" json:"user,omitempty"`
+	User *User `protobuf:"bytes,2,opt,name=user,proto3" json:"user,omitempty"`
+	// When the session expires, if valid
+	ExpiresAt *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	// Why the session is not valid, e.g. "expired" or "revoked"
+	Reason    string `protobuf:"bytes,4,opt,name=reason,proto3" json:"reason,omitempty"`
+	SessionId string `protobuf:"bytes,5,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	// OAuth-style scopes granted to the session
+	Scopes        []string `protobuf:"bytes,6,rep,name=scopes,proto3" json:"scopes,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -331,6 +339,34 @@ func (x *ValidateSessionResponse) GetUser() *User {
 	return nil
 }
 
+func (x *ValidateSessionResponse) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+func (x *ValidateSessionResponse) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *ValidateSessionResponse) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *ValidateSessionResponse) GetScopes() []string {
+	if x != nil {
+		return x.Scopes
+	}
+	return nil
+}
+
 type GetUserRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
@@ -460,10 +496,14 @@ func (x *CreateUserRequest) GetMetadata() *structpb.Struct {
 }
 
 type UpdateUserRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
-	DisplayName   string                 `protobuf:"bytes,3,opt,name=display_name,json=displayName,proto3" json:"display_name,omitempty"`
-	Metadata      *structpb.Struct       `protobuf:"bytes,5,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Id          string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	DisplayName string                 `protobuf:"bytes,3,opt,name=display_name,json=displayName,proto3" json:"display_name,omitempty"`
+	Metadata    *structpb.Struct       `protobuf:"bytes,5,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	// Fields to update, e.g. "display_name", "metadata" or "metadata.plan".
+	// A listed field missing from the request is cleared, an empty mask
+	// replaces every field for compatibility with older clients.
+	UpdateMask    *fieldmaskpb.FieldMask `protobuf:"bytes,6,opt,name=update_mask,json=updateMask,proto3" json:"update_mask,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -519,6 +559,13 @@ func (x *UpdateUserRequest) GetMetadata() *structpb.Struct {
 	return nil
 }
 
+func (x *UpdateUserRequest) GetUpdateMask() *fieldmaskpb.FieldMask {
+	if x != nil {
+		return x.UpdateMask
+	}
+	return nil
+}
+
 type GetTenantRequest struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
 	// Types that are valid to be assigned to Identifier:
@@ -660,9 +707,14 @@ type User struct {
 	TenantId    string                 `protobuf:"bytes,3,opt,name=tenant_id,json=tenantId,proto3" json:"tenant_id,omitempty"`
 	DisplayName string                 `protobuf:"bytes,4,opt,name=display_name,json=displayName,proto3" json:"display_name,omitempty"`
 	// metadata stores provider specific data
-	Metadata      *structpb.Struct       `protobuf:"bytes,5,opt,name=metadata,proto3" json:"metadata,omitempty"`
-	LastLogin     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=last_login,json=lastLogin,proto3" json:"last_login,omitempty"`
-	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	Metadata  *structpb.Struct       `protobuf:"bytes,5,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	LastLogin *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=last_login,json=lastLogin,proto3" json:"last_login,omitempty"`
+	CreatedAt *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	// Roles granted to the user within its tenant
+	Roles []string `protobuf:"bytes,8,rep,name=roles,proto3" json:"roles,omitempty"`
+	// Effective permissions, including the ones granted by the roles
+	Permissions   []string `protobuf:"bytes,9,rep,name=permissions,proto3" json:"permissions,omitempty"`
+	TenantSlug    string   `protobuf:"bytes,10,opt,name=tenant_slug,json=tenantSlug,proto3" json:"tenant_slug,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -746,12 +798,35 @@ func (x *User) GetCreatedAt() *timestamppb.Timestamp {
 	return nil
 }
 
+func (x *User) GetRoles() []string {
+	if x != nil {
+		return x.Roles
+	}
+	return nil
+}
+
+func (x *User) GetPermissions() []string {
+	if x != nil {
+		return x.Permissions
+	}
+	return nil
+}
+
+func (x *User) GetTenantSlug() string {
+	if x != nil {
+		return x.TenantSlug
+	}
+	return ""
+}
+
 type Tenant struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
-	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
-	Slug          string                 `protobuf:"bytes,3,opt,name=slug,proto3" json:"slug,omitempty"`
-	Settings      *structpb.Struct       `protobuf:"bytes,4,opt,name=settings,proto3" json:"settings,omitempty"`
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Id       string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name     string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Slug     string                 `protobuf:"bytes,3,opt,name=slug,proto3" json:"slug,omitempty"`
+	Settings *structpb.Struct       `protobuf:"bytes,4,opt,name=settings,proto3" json:"settings,omitempty"`
+	// Roles defined by the tenant
+	Roles         []*Role `protobuf:"bytes,5,rep,name=roles,proto3" json:"roles,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -814,11 +889,499 @@ func (x *Tenant) GetSettings() *structpb.Struct {
 	return nil
 }
 
+func (x *Tenant) GetRoles() []*Role {
+	if x != nil {
+		return x.Roles
+	}
+	return nil
+}
+
+type Role struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Permissions   []string               `protobuf:"bytes,2,rep,name=permissions,proto3" json:"permissions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Role) Reset() {
+	*x = Role{}
+	mi := &file_v1_identity_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Role) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Role) ProtoMessage() {}
+
+func (x *Role) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_identity_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Role.ProtoReflect.Descriptor instead.
+func (*Role) Descriptor() ([]byte, []int) {
+	return file_v1_identity_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *Role) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Role) GetPermissions() []string {
+	if x != nil {
+		return x.Permissions
+	}
+	return nil
+}
+
+type GetJWKSRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetJWKSRequest) Reset() {
+	*x = GetJWKSRequest{}
+	mi := &file_v1_identity_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetJWKSRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetJWKSRequest) ProtoMessage() {}
+
+func (x *GetJWKSRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_identity_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetJWKSRequest.ProtoReflect.Descriptor instead.
+func (*GetJWKSRequest) Descriptor() ([]byte, []int) {
+	return file_v1_identity_proto_rawDescGZIP(), []int{13}
+}
+
+type GetJWKSResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// JSON Web Key Set document (RFC 7517)
+	Jwks          []byte `protobuf:"bytes,1,opt,name=jwks,proto3" json:"jwks,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetJWKSResponse) Reset() {
+	*x = GetJWKSResponse{}
+	mi := &file_v1_identity_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetJWKSResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetJWKSResponse) ProtoMessage() {}
+
+func (x *GetJWKSResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_identity_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetJWKSResponse.ProtoReflect.Descriptor instead.
+func (*GetJWKSResponse) Descriptor() ([]byte, []int) {
+	return file_v1_identity_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *GetJWKSResponse) GetJwks() []byte {
+	if x != nil {
+		return x.Jwks
+	}
+	return nil
+}
+
+type RefreshSessionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RefreshToken  string                 `protobuf:"bytes,1,opt,name=refresh_token,json=refreshToken,proto3" json:"refresh_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RefreshSessionRequest) Reset() {
+	*x = RefreshSessionRequest{}
+	mi := &file_v1_identity_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RefreshSessionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RefreshSessionRequest) ProtoMessage() {}
+
+func (x *RefreshSessionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_identity_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RefreshSessionRequest.ProtoReflect.Descriptor instead.
+func (*RefreshSessionRequest) Descriptor() ([]byte, []int) {
+	return file_v1_identity_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *RefreshSessionRequest) GetRefreshToken() string {
+	if x != nil {
+		return x.RefreshToken
+	}
+	return ""
+}
+
+type RevokeSessionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Token         string                 `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RevokeSessionRequest) Reset() {
+	*x = RevokeSessionRequest{}
+	mi := &file_v1_identity_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RevokeSessionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RevokeSessionRequest) ProtoMessage() {}
+
+func (x *RevokeSessionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_identity_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RevokeSessionRequest.ProtoReflect.Descriptor instead.
+func (*RevokeSessionRequest) Descriptor() ([]byte, []int) {
+	return file_v1_identity_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *RevokeSessionRequest) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+type RevokeSessionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RevokeSessionResponse) Reset() {
+	*x = RevokeSessionResponse{}
+	mi := &file_v1_identity_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RevokeSessionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RevokeSessionResponse) ProtoMessage() {}
+
+func (x *RevokeSessionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_identity_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RevokeSessionResponse.ProtoReflect.Descriptor instead.
+func (*RevokeSessionResponse) Descriptor() ([]byte, []int) {
+	return file_v1_identity_proto_rawDescGZIP(), []int{17}
+}
+
+type RevokeAllUserSessionsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	TenantId      string                 `protobuf:"bytes,2,opt,name=tenant_id,json=tenantId,proto3" json:"tenant_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RevokeAllUserSessionsRequest) Reset() {
+	*x = RevokeAllUserSessionsRequest{}
+	mi := &file_v1_identity_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RevokeAllUserSessionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RevokeAllUserSessionsRequest) ProtoMessage() {}
+
+func (x *RevokeAllUserSessionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_identity_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RevokeAllUserSessionsRequest.ProtoReflect.Descriptor instead.
+func (*RevokeAllUserSessionsRequest) Descriptor() ([]byte, []int) {
+	return file_v1_identity_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *RevokeAllUserSessionsRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *RevokeAllUserSessionsRequest) GetTenantId() string {
+	if x != nil {
+		return x.TenantId
+	}
+	return ""
+}
+
+type RevokeAllUserSessionsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RevokedCount  int32                  `protobuf:"varint,1,opt,name=revoked_count,json=revokedCount,proto3" json:"revoked_count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RevokeAllUserSessionsResponse) Reset() {
+	*x = RevokeAllUserSessionsResponse{}
+	mi := &file_v1_identity_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RevokeAllUserSessionsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RevokeAllUserSessionsResponse) ProtoMessage() {}
+
+func (x *RevokeAllUserSessionsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_identity_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RevokeAllUserSessionsResponse.ProtoReflect.Descriptor instead.
+func (*RevokeAllUserSessionsResponse) Descriptor() ([]byte, []int) {
+	return file_v1_identity_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *RevokeAllUserSessionsResponse) GetRevokedCount() int32 {
+	if x != nil {
+		return x.RevokedCount
+	}
+	return 0
+}
+
+type WatchRevocationsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchRevocationsRequest) Reset() {
+	*x = WatchRevocationsRequest{}
+	mi := &file_v1_identity_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchRevocationsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchRevocationsRequest) ProtoMessage() {}
+
+func (x *WatchRevocationsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_identity_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchRevocationsRequest.ProtoReflect.Descriptor instead.
+func (*WatchRevocationsRequest) Descriptor() ([]byte, []int) {
+	return file_v1_identity_proto_rawDescGZIP(), []int{20}
+}
+
+type RevocationEvent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Target:
+	//
+	//	*RevocationEvent_TokenSha256
+	//	*RevocationEvent_UserId
+	Target        isRevocationEvent_Target `protobuf_oneof:"target"`
+	RevokedAt     *timestamppb.Timestamp   `protobuf:"bytes,3,opt,name=revoked_at,json=revokedAt,proto3" json:"revoked_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RevocationEvent) Reset() {
+	*x = RevocationEvent{}
+	mi := &file_v1_identity_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RevocationEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RevocationEvent) ProtoMessage() {}
+
+func (x *RevocationEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_v1_identity_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RevocationEvent.ProtoReflect.Descriptor instead.
+func (*RevocationEvent) Descriptor() ([]byte, []int) {
+	return file_v1_identity_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *RevocationEvent) GetTarget() isRevocationEvent_Target {
+	if x != nil {
+		return x.Target
+	}
+	return nil
+}
+
+func (x *RevocationEvent) GetTokenSha256() []byte {
+	if x != nil {
+		if x, ok := x.Target.(*RevocationEvent_TokenSha256); ok {
+			return x.TokenSha256
+		}
+	}
+	return nil
+}
+
+func (x *RevocationEvent) GetUserId() string {
+	if x != nil {
+		if x, ok := x.Target.(*RevocationEvent_UserId); ok {
+			return x.UserId
+		}
+	}
+	return ""
+}
+
+func (x *RevocationEvent) GetRevokedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.RevokedAt
+	}
+	return nil
+}
+
+type isRevocationEvent_Target interface {
+	isRevocationEvent_Target()
+}
+
+type RevocationEvent_TokenSha256 struct {
+	// SHA-256 digest of the revoked token
+	TokenSha256 []byte `protobuf:"bytes,1,opt,name=token_sha256,json=tokenSha256,proto3,oneof"`
+}
+
+type RevocationEvent_UserId struct {
+	// Every session of the user was revoked
+	UserId string `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3,oneof"`
+}
+
+func (*RevocationEvent_TokenSha256) isRevocationEvent_Target() {}
+
+func (*RevocationEvent_UserId) isRevocationEvent_Target() {}
+
 var File_v1_identity_proto protoreflect.FileDescriptor
 
 const file_v1_identity_proto_rawDesc = "" +
 	"\n" +
-	"\x11v1/identity.proto\x12\videntity.v1\x1a\x1cgoogle/protobuf/struct.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\xae\x01\n" +
+	"\x11v1/identity.proto\x12\videntity.v1\x1a google/protobuf/field_mask.proto\x1a\x1cgoogle/protobuf/struct.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\xae\x01\n" +
 	"\x13AuthenticateRequest\x12\x1f\n" +
 	"\vtenant_slug\x18\x01 \x01(\tR\n" +
 	"tenantSlug\x12'\n" +
@@ -837,10 +1400,16 @@ const file_v1_identity_proto_rawDesc = "" +
 	"\x05email\x18\x01 \x01(\tR\x05email\x12\x1a\n" +
 	"\bpassword\x18\x02 \x01(\tR\bpassword\".\n" +
 	"\x16ValidateSessionRequest\x12\x14\n" +
-	"\x05token\x18\x01 \x01(\tR\x05token\"V\n" +
+	"\x05token\x18\x01 \x01(\tR\x05token\"\xe0\x01\n" +
 	"\x17ValidateSessionResponse\x12\x14\n" +
 	"\x05valid\x18\x01 \x01(\bR\x05valid\x12%\n" +
-	"\x04user\x18\x02 \x01(\v2\x11.identity.v1.UserR\x04user\"=\n" +
+	"\x04user\x18\x02 \x01(\v2\x11.identity.v1.UserR\x04user\x129\n" +
+	"\n" +
+	"expires_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\x12\x16\n" +
+	"\x06reason\x18\x04 \x01(\tR\x06reason\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x05 \x01(\tR\tsessionId\x12\x16\n" +
+	"\x06scopes\x18\x06 \x03(\tR\x06scopes\"=\n" +
 	"\x0eGetUserRequest\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1b\n" +
 	"\ttenant_id\x18\x02 \x01(\tR\btenantId\"\xba\x01\n" +
@@ -849,11 +1418,13 @@ const file_v1_identity_proto_rawDesc = "" +
 	"\ttenant_id\x18\x02 \x01(\tR\btenantId\x12!\n" +
 	"\fdisplay_name\x18\x03 \x01(\tR\vdisplayName\x12\x1a\n" +
 	"\bpassword\x18\x04 \x01(\tR\bpassword\x123\n" +
-	"\bmetadata\x18\x05 \x01(\v2\x17.google.protobuf.StructR\bmetadata\"{\n" +
+	"\bmetadata\x18\x05 \x01(\v2\x17.google.protobuf.StructR\bmetadata\"\xb8\x01\n" +
 	"\x11UpdateUserRequest\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12!\n" +
 	"\fdisplay_name\x18\x03 \x01(\tR\vdisplayName\x123\n" +
-	"\bmetadata\x18\x05 \x01(\v2\x17.google.protobuf.StructR\bmetadata\"H\n" +
+	"\bmetadata\x18\x05 \x01(\v2\x17.google.protobuf.StructR\bmetadata\x12;\n" +
+	"\vupdate_mask\x18\x06 \x01(\v2\x1a.google.protobuf.FieldMaskR\n" +
+	"updateMask\"H\n" +
 	"\x10GetTenantRequest\x12\x10\n" +
 	"\x02id\x18\x01 \x01(\tH\x00R\x02id\x12\x14\n" +
 	"\x04slug\x18\x02 \x01(\tH\x00R\x04slugB\f\n" +
@@ -861,7 +1432,7 @@ const file_v1_identity_proto_rawDesc = "" +
 	"identifier\"=\n" +
 	"\x13CreateTenantRequest\x12\x12\n" +
 	"\x04name\x18\x01 \x01(\tR\x04name\x12\x12\n" +
-	"\x04slug\x18\x02 \x01(\tR\x04slug\"\x97\x02\n" +
+	"\x04slug\x18\x02 \x01(\tR\x04slug\"\xf0\x02\n" +
 	"\x04User\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x14\n" +
 	"\x05email\x18\x02 \x01(\tR\x05email\x12\x1b\n" +
@@ -871,22 +1442,56 @@ const file_v1_identity_proto_rawDesc = "" +
 	"\n" +
 	"last_login\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tlastLogin\x129\n" +
 	"\n" +
-	"created_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\"u\n" +
+	"created_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x12\x14\n" +
+	"\x05roles\x18\b \x03(\tR\x05roles\x12 \n" +
+	"\vpermissions\x18\t \x03(\tR\vpermissions\x12\x1f\n" +
+	"\vtenant_slug\x18\n" +
+	" \x01(\tR\n" +
+	"tenantSlug\"\x9e\x01\n" +
 	"\x06Tenant\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
 	"\x04name\x18\x02 \x01(\tR\x04name\x12\x12\n" +
 	"\x04slug\x18\x03 \x01(\tR\x04slug\x123\n" +
-	"\bsettings\x18\x04 \x01(\v2\x17.google.protobuf.StructR\bsettings2\x89\x04\n" +
+	"\bsettings\x18\x04 \x01(\v2\x17.google.protobuf.StructR\bsettings\x12'\n" +
+	"\x05roles\x18\x05 \x03(\v2\x11.identity.v1.RoleR\x05roles\"<\n" +
+	"\x04Role\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12 \n" +
+	"\vpermissions\x18\x02 \x03(\tR\vpermissions\"\x10\n" +
+	"\x0eGetJWKSRequest\"%\n" +
+	"\x0fGetJWKSResponse\x12\x12\n" +
+	"\x04jwks\x18\x01 \x01(\fR\x04jwks\"<\n" +
+	"\x15RefreshSessionRequest\x12#\n" +
+	"\rrefresh_token\x18\x01 \x01(\tR\frefreshToken\",\n" +
+	"\x14RevokeSessionRequest\x12\x14\n" +
+	"\x05token\x18\x01 \x01(\tR\x05token\"\x17\n" +
+	"\x15RevokeSessionResponse\"T\n" +
+	"\x1cRevokeAllUserSessionsRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x1b\n" +
+	"\ttenant_id\x18\x02 \x01(\tR\btenantId\"D\n" +
+	"\x1dRevokeAllUserSessionsResponse\x12#\n" +
+	"\rrevoked_count\x18\x01 \x01(\x05R\frevokedCount\"\x19\n" +
+	"\x17WatchRevocationsRequest\"\x96\x01\n" +
+	"\x0fRevocationEvent\x12#\n" +
+	"\ftoken_sha256\x18\x01 \x01(\fH\x00R\vtokenSha256\x12\x19\n" +
+	"\auser_id\x18\x02 \x01(\tH\x00R\x06userId\x129\n" +
+	"\n" +
+	"revoked_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\trevokedAtB\b\n" +
+	"\x06target2\xca\a\n" +
 	"\x0fIdentityService\x12S\n" +
 	"\fAuthenticate\x12 .identity.v1.AuthenticateRequest\x1a!.identity.v1.AuthenticateResponse\x12\\\n" +
-	"\x0fValidateSession\x12#.identity.v1.ValidateSessionRequest\x1a$.identity.v1.ValidateSessionResponse\x129\n" +
+	"\x0fValidateSession\x12#.identity.v1.ValidateSessionRequest\x1a$.identity.v1.ValidateSessionResponse\x12W\n" +
+	"\x0eRefreshSession\x12\".identity.v1.RefreshSessionRequest\x1a!.identity.v1.AuthenticateResponse\x12V\n" +
+	"\rRevokeSession\x12!.identity.v1.RevokeSessionRequest\x1a\".identity.v1.RevokeSessionResponse\x12n\n" +
+	"\x15RevokeAllUserSessions\x12).identity.v1.RevokeAllUserSessionsRequest\x1a*.identity.v1.RevokeAllUserSessionsResponse\x12X\n" +
+	"\x10WatchRevocations\x12$.identity.v1.WatchRevocationsRequest\x1a\x1c.identity.v1.RevocationEvent0\x01\x129\n" +
 	"\aGetUser\x12\x1b.identity.v1.GetUserRequest\x1a\x11.identity.v1.User\x12?\n" +
 	"\n" +
 	"CreateUser\x12\x1e.identity.v1.CreateUserRequest\x1a\x11.identity.v1.User\x12?\n" +
 	"\n" +
 	"UpdateUser\x12\x1e.identity.v1.UpdateUserRequest\x1a\x11.identity.v1.User\x12?\n" +
 	"\tGetTenant\x12\x1d.identity.v1.GetTenantRequest\x1a\x13.identity.v1.Tenant\x12E\n" +
-	"\fCreateTenant\x12 .identity.v1.CreateTenantRequest\x1a\x13.identity.v1.TenantB8Z6github.com/kodeart/identity-sdk-go/proto/v1;identityv1b\x06proto3"
+	"\fCreateTenant\x12 .identity.v1.CreateTenantRequest\x1a\x13.identity.v1.Tenant\x12D\n" +
+	"\aGetJWKS\x12\x1b.identity.v1.GetJWKSRequest\x1a\x1c.identity.v1.GetJWKSResponseB8Z6github.com/kodeart/identity-sdk-go/proto/v1;identityv1b\x06proto3"
 
 var (
 	file_v1_identity_proto_rawDescOnce sync.Once
@@ -900,53 +1505,78 @@ func file_v1_identity_proto_rawDescGZIP() []byte {
 	return file_v1_identity_proto_rawDescData
 }
 
-var file_v1_identity_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
+var file_v1_identity_proto_msgTypes = make([]protoimpl.MessageInfo, 22)
 var file_v1_identity_proto_goTypes = []any{
-	(*AuthenticateRequest)(nil),     // 0: identity.v1.AuthenticateRequest
-	(*AuthenticateResponse)(nil),    // 1: identity.v1.AuthenticateResponse
-	(*UserCredentials)(nil),         // 2: identity.v1.UserCredentials
-	(*ValidateSessionRequest)(nil),  // 3: identity.v1.ValidateSessionRequest
-	(*ValidateSessionResponse)(nil), // 4: identity.v1.ValidateSessionResponse
-	(*GetUserRequest)(nil),          // 5: identity.v1.GetUserRequest
-	(*CreateUserRequest)(nil),       // 6: identity.v1.CreateUserRequest
-	(*UpdateUserRequest)(nil),       // 7: identity.v1.UpdateUserRequest
-	(*GetTenantRequest)(nil),        // 8: identity.v1.GetTenantRequest
-	(*CreateTenantRequest)(nil),     // 9: identity.v1.CreateTenantRequest
-	(*User)(nil),                    // 10: identity.v1.User
-	(*Tenant)(nil),                  // 11: identity.v1.Tenant
-	(*timestamppb.Timestamp)(nil),   // 12: google.protobuf.Timestamp
-	(*structpb.Struct)(nil),         // 13: google.protobuf.Struct
+	(*AuthenticateRequest)(nil),           // 0: identity.v1.AuthenticateRequest
+	(*AuthenticateResponse)(nil),          // 1: identity.v1.AuthenticateResponse
+	(*UserCredentials)(nil),               // 2: identity.v1.UserCredentials
+	(*ValidateSessionRequest)(nil),        // 3: identity.v1.ValidateSessionRequest
+	(*ValidateSessionResponse)(nil),       // 4: identity.v1.ValidateSessionResponse
+	(*GetUserRequest)(nil),                // 5: identity.v1.GetUserRequest
+	(*CreateUserRequest)(nil),             // 6: identity.v1.CreateUserRequest
+	(*UpdateUserRequest)(nil),             // 7: identity.v1.UpdateUserRequest
+	(*GetTenantRequest)(nil),              // 8: identity.v1.GetTenantRequest
+	(*CreateTenantRequest)(nil),           // 9: identity.v1.CreateTenantRequest
+	(*User)(nil),                          // 10: identity.v1.User
+	(*Tenant)(nil),                        // 11: identity.v1.Tenant
+	(*Role)(nil),                          // 12: identity.v1.Role
+	(*GetJWKSRequest)(nil),                // 13: identity.v1.GetJWKSRequest
+	(*GetJWKSResponse)(nil),               // 14: identity.v1.GetJWKSResponse
+	(*RefreshSessionRequest)(nil),         // 15: identity.v1.RefreshSessionRequest
+	(*RevokeSessionRequest)(nil),          // 16: identity.v1.RevokeSessionRequest
+	(*RevokeSessionResponse)(nil),         // 17: identity.v1.RevokeSessionResponse
+	(*RevokeAllUserSessionsRequest)(nil),  // 18: identity.v1.RevokeAllUserSessionsRequest
+	(*RevokeAllUserSessionsResponse)(nil), // 19: identity.v1.RevokeAllUserSessionsResponse
+	(*WatchRevocationsRequest)(nil),       // 20: identity.v1.WatchRevocationsRequest
+	(*RevocationEvent)(nil),               // 21: identity.v1.RevocationEvent
+	(*timestamppb.Timestamp)(nil),         // 22: google.protobuf.Timestamp
+	(*structpb.Struct)(nil),               // 23: google.protobuf.Struct
+	(*fieldmaskpb.FieldMask)(nil),         // 24: google.protobuf.FieldMask
 }
 var file_v1_identity_proto_depIdxs = []int32{
 	2,  // 0: identity.v1.AuthenticateRequest.credential:type_name -> identity.v1.UserCredentials
-	12, // 1: identity.v1.AuthenticateResponse.expires_at:type_name -> google.protobuf.Timestamp
+	22, // 1: identity.v1.AuthenticateResponse.expires_at:type_name -> google.protobuf.Timestamp
 	10, // 2: identity.v1.AuthenticateResponse.user:type_name -> identity.v1.User
 	10, // 3: identity.v1.ValidateSessionResponse.user:type_name -> identity.v1.User
-	13, // 4: identity.v1.CreateUserRequest.metadata:type_name -> google.protobuf.Struct
-	13, // 5: identity.v1.UpdateUserRequest.metadata:type_name -> google.protobuf.Struct
-	13, // 6: identity.v1.User.metadata:type_name -> google.protobuf.Struct
-	12, // 7: identity.v1.User.last_login:type_name -> google.protobuf.Timestamp
-	12, // 8: identity.v1.User.created_at:type_name -> google.protobuf.Timestamp
-	13, // 9: identity.v1.Tenant.settings:type_name -> google.protobuf.Struct
-	0,  // 10: identity.v1.IdentityService.Authenticate:input_type -> identity.v1.AuthenticateRequest
-	3,  // 11: identity.v1.IdentityService.ValidateSession:input_type -> identity.v1.ValidateSessionRequest
-	5,  // 12: identity.v1.IdentityService.GetUser:input_type -> identity.v1.GetUserRequest
-	6,  // 13: identity.v1.IdentityService.CreateUser:input_type -> identity.v1.CreateUserRequest
-	7,  // 14: identity.v1.IdentityService.UpdateUser:input_type -> identity.v1.UpdateUserRequest
-	8,  // 15: identity.v1.IdentityService.GetTenant:input_type -> identity.v1.GetTenantRequest
-	9,  // 16: identity.v1.IdentityService.CreateTenant:input_type -> identity.v1.CreateTenantRequest
-	1,  // 17: identity.v1.IdentityService.Authenticate:output_type -> identity.v1.AuthenticateResponse
-	4,  // 18: identity.v1.IdentityService.ValidateSession:output_type -> identity.v1.ValidateSessionResponse
-	10, // 19: identity.v1.IdentityService.GetUser:output_type -> identity.v1.User
-	10, // 20: identity.v1.IdentityService.CreateUser:output_type -> identity.v1.User
-	10, // 21: identity.v1.IdentityService.UpdateUser:output_type -> identity.v1.User
-	11, // 22: identity.v1.IdentityService.GetTenant:output_type -> identity.v1.Tenant
-	11, // 23: identity.v1.IdentityService.CreateTenant:output_type -> identity.v1.Tenant
-	17, // [17:24] is the sub-list for method output_type
-	10, // [10:17] is the sub-list for method input_type
-	10, // [10:10] is the sub-list for extension type_name
-	10, // [10:10] is the sub-list for extension extendee
-	0,  // [0:10] is the sub-list for field type_name
+	22, // 4: identity.v1.ValidateSessionResponse.expires_at:type_name -> google.protobuf.Timestamp
+	23, // 5: identity.v1.CreateUserRequest.metadata:type_name -> google.protobuf.Struct
+	23, // 6: identity.v1.UpdateUserRequest.metadata:type_name -> google.protobuf.Struct
+	24, // 7: identity.v1.UpdateUserRequest.update_mask:type_name -> google.protobuf.FieldMask
+	23, // 8: identity.v1.User.metadata:type_name -> google.protobuf.Struct
+	22, // 9: identity.v1.User.last_login:type_name -> google.protobuf.Timestamp
+	22, // 10: identity.v1.User.created_at:type_name -> google.protobuf.Timestamp
+	23, // 11: identity.v1.Tenant.settings:type_name -> google.protobuf.Struct
+	12, // 12: identity.v1.Tenant.roles:type_name -> identity.v1.Role
+	22, // 13: identity.v1.RevocationEvent.revoked_at:type_name -> google.protobuf.Timestamp
+	0,  // 14: identity.v1.IdentityService.Authenticate:input_type -> identity.v1.AuthenticateRequest
+	3,  // 15: identity.v1.IdentityService.ValidateSession:input_type -> identity.v1.ValidateSessionRequest
+	15, // 16: identity.v1.IdentityService.RefreshSession:input_type -> identity.v1.RefreshSessionRequest
+	16, // 17: identity.v1.IdentityService.RevokeSession:input_type -> identity.v1.RevokeSessionRequest
+	18, // 18: identity.v1.IdentityService.RevokeAllUserSessions:input_type -> identity.v1.RevokeAllUserSessionsRequest
+	20, // 19: identity.v1.IdentityService.WatchRevocations:input_type -> identity.v1.WatchRevocationsRequest
+	5,  // 20: identity.v1.IdentityService.GetUser:input_type -> identity.v1.GetUserRequest
+	6,  // 21: identity.v1.IdentityService.CreateUser:input_type -> identity.v1.CreateUserRequest
+	7,  // 22: identity.v1.IdentityService.UpdateUser:input_type -> identity.v1.UpdateUserRequest
+	8,  // 23: identity.v1.IdentityService.GetTenant:input_type -> identity.v1.GetTenantRequest
+	9,  // 24: identity.v1.IdentityService.CreateTenant:input_type -> identity.v1.CreateTenantRequest
+	13, // 25: identity.v1.IdentityService.GetJWKS:input_type -> identity.v1.GetJWKSRequest
+	1,  // 26: identity.v1.IdentityService.Authenticate:output_type -> identity.v1.AuthenticateResponse
+	4,  // 27: identity.v1.IdentityService.ValidateSession:output_type -> identity.v1.ValidateSessionResponse
+	1,  // 28: identity.v1.IdentityService.RefreshSession:output_type -> identity.v1.AuthenticateResponse
+	17, // 29: identity.v1.IdentityService.RevokeSession:output_type -> identity.v1.RevokeSessionResponse
+	19, // 30: identity.v1.IdentityService.RevokeAllUserSessions:output_type -> identity.v1.RevokeAllUserSessionsResponse
+	21, // 31: identity.v1.IdentityService.WatchRevocations:output_type -> identity.v1.RevocationEvent
+	10, // 32: identity.v1.IdentityService.GetUser:output_type -> identity.v1.User
+	10, // 33: identity.v1.IdentityService.CreateUser:output_type -> identity.v1.User
+	10, // 34: identity.v1.IdentityService.UpdateUser:output_type -> identity.v1.User
+	11, // 35: identity.v1.IdentityService.GetTenant:output_type -> identity.v1.Tenant
+	11, // 36: identity.v1.IdentityService.CreateTenant:output_type -> identity.v1.Tenant
+	14, // 37: identity.v1.IdentityService.GetJWKS:output_type -> identity.v1.GetJWKSResponse
+	26, // [26:38] is the sub-list for method output_type
+	14, // [14:26] is the sub-list for method input_type
+	14, // [14:14] is the sub-list for extension type_name
+	14, // [14:14] is the sub-list for extension extendee
+	0,  // [0:14] is the sub-list for field type_name
 }
 
 func init() { file_v1_identity_proto_init() }
@@ -962,13 +1592,17 @@ func file_v1_identity_proto_init() {
 		(*GetTenantRequest_Id)(nil),
 		(*GetTenantRequest_Slug)(nil),
 	}
+	file_v1_identity_proto_msgTypes[21].OneofWrappers = []any{
+		(*RevocationEvent_TokenSha256)(nil),
+		(*RevocationEvent_UserId)(nil),
+	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_v1_identity_proto_rawDesc), len(file_v1_identity_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   12,
+			NumMessages:   22,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
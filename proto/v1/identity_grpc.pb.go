@@ -19,13 +19,18 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	IdentityService_Authenticate_FullMethodName    = "/identity.v1.IdentityService/Authenticate"
-	IdentityService_ValidateSession_FullMethodName = "/identity.v1.IdentityService/ValidateSession"
-	IdentityService_GetUser_FullMethodName         = "/identity.v1.IdentityService/GetUser"
-	IdentityService_CreateUser_FullMethodName      = "/identity.v1.IdentityService/CreateUser"
-	IdentityService_UpdateUser_FullMethodName      = "/identity.v1.IdentityService/UpdateUser"
-	IdentityService_GetTenant_FullMethodName       = "/identity.v1.IdentityService/GetTenant"
-	IdentityService_CreateTenant_FullMethodName    = "/identity.v1.IdentityService/CreateTenant"
+	IdentityService_Authenticate_FullMethodName          = "/identity.v1.IdentityService/Authenticate"
+	IdentityService_ValidateSession_FullMethodName       = "/identity.v1.IdentityService/ValidateSession"
+	IdentityService_RefreshSession_FullMethodName        = "/identity.v1.IdentityService/RefreshSession"
+	IdentityService_RevokeSession_FullMethodName         = "/identity.v1.IdentityService/RevokeSession"
+	IdentityService_RevokeAllUserSessions_FullMethodName = "/identity.v1.IdentityService/RevokeAllUserSessions"
+	IdentityService_WatchRevocations_FullMethodName      = "/identity.v1.IdentityService/WatchRevocations"
+	IdentityService_GetUser_FullMethodName               = "/identity.v1.IdentityService/GetUser"
+	IdentityService_CreateUser_FullMethodName            = "/identity.v1.IdentityService/CreateUser"
+	IdentityService_UpdateUser_FullMethodName            = "/identity.v1.IdentityService/UpdateUser"
+	IdentityService_GetTenant_FullMethodName             = "/identity.v1.IdentityService/GetTenant"
+	IdentityService_CreateTenant_FullMethodName          = "/identity.v1.IdentityService/CreateTenant"
+	IdentityService_GetJWKS_FullMethodName               = "/identity.v1.IdentityService/GetJWKS"
 )
 
 // IdentityServiceClient is the client API for IdentityService service.
@@ -37,11 +42,24 @@ type IdentityServiceClient interface {
 	Authenticate(ctx context.Context, in *AuthenticateRequest, opts ...grpc.CallOption) (*AuthenticateResponse, error)
 	// ValidateSession is used by the SDK/Middleware to check if a token is still valid.
 	ValidateSession(ctx context.Context, in *ValidateSessionRequest, opts ...grpc.CallOption) (*ValidateSessionResponse, error)
+	// RefreshSession exchanges a refresh token for a new session.
+	// The refresh token may be rotated, clients must keep the returned one.
+	RefreshSession(ctx context.Context, in *RefreshSessionRequest, opts ...grpc.CallOption) (*AuthenticateResponse, error)
+	// RevokeSession invalidates a single session, e.g. on logout.
+	RevokeSession(ctx context.Context, in *RevokeSessionRequest, opts ...grpc.CallOption) (*RevokeSessionResponse, error)
+	// RevokeAllUserSessions invalidates every session of the user.
+	RevokeAllUserSessions(ctx context.Context, in *RevokeAllUserSessionsRequest, opts ...grpc.CallOption) (*RevokeAllUserSessionsResponse, error)
+	// WatchRevocations streams the revoked sessions, so the SDK caches
+	// can drop them right away.
+	WatchRevocations(ctx context.Context, in *WatchRevocationsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[RevocationEvent], error)
 	GetUser(ctx context.Context, in *GetUserRequest, opts ...grpc.CallOption) (*User, error)
 	CreateUser(ctx context.Context, in *CreateUserRequest, opts ...grpc.CallOption) (*User, error)
 	UpdateUser(ctx context.Context, in *UpdateUserRequest, opts ...grpc.CallOption) (*User, error)
 	GetTenant(ctx context.Context, in *GetTenantRequest, opts ...grpc.CallOption) (*Tenant, error)
 	CreateTenant(ctx context.Context, in *CreateTenantRequest, opts ...grpc.CallOption) (*Tenant, error)
+	// GetJWKS returns the public keys used to sign the session tokens,
+	// so they can be verified offline.
+	GetJWKS(ctx context.Context, in *GetJWKSRequest, opts ...grpc.CallOption) (*GetJWKSResponse, error)
 }
 
 type identityServiceClient struct {
@@ -72,6 +90,55 @@ func (c *identityServiceClient) ValidateSession(ctx context.Context, in *Validat
 	return out, nil
 }
 
+func (c *identityServiceClient) RefreshSession(ctx context.Context, in *RefreshSessionRequest, opts ...grpc.CallOption) (*AuthenticateResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AuthenticateResponse)
+	err := c.cc.Invoke(ctx, IdentityService_RefreshSession_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *identityServiceClient) RevokeSession(ctx context.Context, in *RevokeSessionRequest, opts ...grpc.CallOption) (*RevokeSessionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RevokeSessionResponse)
+	err := c.cc.Invoke(ctx, IdentityService_RevokeSession_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *identityServiceClient) RevokeAllUserSessions(ctx context.Context, in *RevokeAllUserSessionsRequest, opts ...grpc.CallOption) (*RevokeAllUserSessionsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RevokeAllUserSessionsResponse)
+	err := c.cc.Invoke(ctx, IdentityService_RevokeAllUserSessions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *identityServiceClient) WatchRevocations(ctx context.Context, in *WatchRevocationsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[RevocationEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &IdentityService_ServiceDesc.Streams[0], IdentityService_WatchRevocations_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchRevocationsRequest, RevocationEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type IdentityService_WatchRevocationsClient = grpc.ServerStreamingClient[RevocationEvent]
+
 func (c *identityServiceClient) GetUser(ctx context.Context, in *GetUserRequest, opts ...grpc.CallOption) (*User, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(User)
@@ -122,6 +189,16 @@ func (c *identityServiceClient) CreateTenant(ctx context.Context, in *CreateTena
 	return out, nil
 }
 
+func (c *identityServiceClient) GetJWKS(ctx context.Context, in *GetJWKSRequest, opts ...grpc.CallOption) (*GetJWKSResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetJWKSResponse)
+	err := c.cc.Invoke(ctx, IdentityService_GetJWKS_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // IdentityServiceServer is the server API for IdentityService service.
 // All implementations should embed UnimplementedIdentityServiceServer
 // for forward compatibility.
@@ -131,11 +208,24 @@ type IdentityServiceServer interface {
 	Authenticate(context.Context, *AuthenticateRequest) (*AuthenticateResponse, error)
 	// ValidateSession is used by the SDK/Middleware to check if a token is still valid.
 	ValidateSession(context.Context, *ValidateSessionRequest) (*ValidateSessionResponse, error)
+	// RefreshSession exchanges a refresh token for a new session.
+	// The refresh token may be rotated, clients must keep the returned one.
+	RefreshSession(context.Context, *RefreshSessionRequest) (*AuthenticateResponse, error)
+	// RevokeSession invalidates a single session, e.g. on logout.
+	RevokeSession(context.Context, *RevokeSessionRequest) (*RevokeSessionResponse, error)
+	// RevokeAllUserSessions invalidates every session of the user.
+	RevokeAllUserSessions(context.Context, *RevokeAllUserSessionsRequest) (*RevokeAllUserSessionsResponse, error)
+	// WatchRevocations streams the revoked sessions, so the SDK caches
+	// can drop them right away.
+	WatchRevocations(*WatchRevocationsRequest, grpc.ServerStreamingServer[RevocationEvent]) error
 	GetUser(context.Context, *GetUserRequest) (*User, error)
 	CreateUser(context.Context, *CreateUserRequest) (*User, error)
 	UpdateUser(context.Context, *UpdateUserRequest) (*User, error)
 	GetTenant(context.Context, *GetTenantRequest) (*Tenant, error)
 	CreateTenant(context.Context, *CreateTenantRequest) (*Tenant, error)
+	// GetJWKS returns the public keys used to sign the session tokens,
+	// so they can be verified offline.
+	GetJWKS(context.Context, *GetJWKSRequest) (*GetJWKSResponse, error)
 }
 
 // UnimplementedIdentityServiceServer should be embedded to have
@@ -151,6 +241,18 @@ func (UnimplementedIdentityServiceServer) Authenticate(context.Context, *Authent
 func (UnimplementedIdentityServiceServer) ValidateSession(context.Context, *ValidateSessionRequest) (*ValidateSessionResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method ValidateSession not implemented")
 }
+func (UnimplementedIdentityServiceServer) RefreshSession(context.Context, *RefreshSessionRequest) (*AuthenticateResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method RefreshSession not implemented")
+}
+func (UnimplementedIdentityServiceServer) RevokeSession(context.Context, *RevokeSessionRequest) (*RevokeSessionResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method RevokeSession not implemented")
+}
+func (UnimplementedIdentityServiceServer) RevokeAllUserSessions(context.Context, *RevokeAllUserSessionsRequest) (*RevokeAllUserSessionsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method RevokeAllUserSessions not implemented")
+}
+func (UnimplementedIdentityServiceServer) WatchRevocations(*WatchRevocationsRequest, grpc.ServerStreamingServer[RevocationEvent]) error {
+	return status.Error(codes.Unimplemented, "method WatchRevocations not implemented")
+}
 func (UnimplementedIdentityServiceServer) GetUser(context.Context, *GetUserRequest) (*User, error) {
 	return nil, status.Error(codes.Unimplemented, "method GetUser not implemented")
 }
@@ -166,6 +268,9 @@ func (UnimplementedIdentityServiceServer) GetTenant(context.Context, *GetTenantR
 func (UnimplementedIdentityServiceServer) CreateTenant(context.Context, *CreateTenantRequest) (*Tenant, error) {
 	return nil, status.Error(codes.Unimplemented, "method CreateTenant not implemented")
 }
+func (UnimplementedIdentityServiceServer) GetJWKS(context.Context, *GetJWKSRequest) (*GetJWKSResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetJWKS not implemented")
+}
 func (UnimplementedIdentityServiceServer) testEmbeddedByValue() {}
 
 // UnsafeIdentityServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -222,6 +327,71 @@ func _IdentityService_ValidateSession_Handler(srv interface{}, ctx context.Conte
 	return interceptor(ctx, in, info, handler)
 }
 
+func _IdentityService_RefreshSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RefreshSessionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IdentityServiceServer).RefreshSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: IdentityService_RefreshSession_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IdentityServiceServer).RefreshSession(ctx, req.(*RefreshSessionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IdentityService_RevokeSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RevokeSessionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IdentityServiceServer).RevokeSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: IdentityService_RevokeSession_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IdentityServiceServer).RevokeSession(ctx, req.(*RevokeSessionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IdentityService_RevokeAllUserSessions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RevokeAllUserSessionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IdentityServiceServer).RevokeAllUserSessions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: IdentityService_RevokeAllUserSessions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IdentityServiceServer).RevokeAllUserSessions(ctx, req.(*RevokeAllUserSessionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IdentityService_WatchRevocations_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRevocationsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(IdentityServiceServer).WatchRevocations(m, &grpc.GenericServerStream[WatchRevocationsRequest, RevocationEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type IdentityService_WatchRevocationsServer = grpc.ServerStreamingServer[RevocationEvent]
+
 func _IdentityService_GetUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(GetUserRequest)
 	if err := dec(in); err != nil {
@@ -312,6 +482,24 @@ func _IdentityService_CreateTenant_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _IdentityService_GetJWKS_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetJWKSRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IdentityServiceServer).GetJWKS(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: IdentityService_GetJWKS_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IdentityServiceServer).GetJWKS(ctx, req.(*GetJWKSRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // IdentityService_ServiceDesc is the grpc.ServiceDesc for IdentityService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -327,6 +515,18 @@ var IdentityService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ValidateSession",
 			Handler:    _IdentityService_ValidateSession_Handler,
 		},
+		{
+			MethodName: "RefreshSession",
+			Handler:    _IdentityService_RefreshSession_Handler,
+		},
+		{
+			MethodName: "RevokeSession",
+			Handler:    _IdentityService_RevokeSession_Handler,
+		},
+		{
+			MethodName: "RevokeAllUserSessions",
+			Handler:    _IdentityService_RevokeAllUserSessions_Handler,
+		},
 		{
 			MethodName: "GetUser",
 			Handler:    _IdentityService_GetUser_Handler,
@@ -347,7 +547,17 @@ var IdentityService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "CreateTenant",
 			Handler:    _IdentityService_CreateTenant_Handler,
 		},
+		{
+			MethodName: "GetJWKS",
+			Handler:    _IdentityService_GetJWKS_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchRevocations",
+			Handler:       _IdentityService_WatchRevocations_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "v1/identity.proto",
 }
@@ -0,0 +1,196 @@
+package identity
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	pb "github.com/kodeart/identity-sdk-go/proto/v1"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RevokeSession invalidates the session of the token, e.g. on logout.
+// The token is dropped from the local cache right away.
+func (c *Client) RevokeSession(ctx context.Context, token string) error {
+	if _, err := c.grpcsvc.RevokeSession(ctx, &pb.RevokeSessionRequest{Token: token}); err != nil {
+		return err
+	}
+	if c.cache != nil {
+		c.cache.revokeToken(keyOf(token))
+	}
+	return nil
+}
+
+// RevokeAllUserSessions invalidates every session of the user and
+// returns how many were revoked. The user sessions are dropped
+// from the local cache right away.
+func (c *Client) RevokeAllUserSessions(ctx context.Context, tenantID, userID string) (int, error) {
+	resp, err := c.grpcsvc.RevokeAllUserSessions(ctx, &pb.RevokeAllUserSessionsRequest{
+		UserId:   userID,
+		TenantId: tenantID,
+	})
+	if err != nil {
+		return 0, err
+	}
+	if c.cache != nil {
+		c.cache.revokeUser(userID)
+	}
+	return int(resp.GetRevokedCount()), nil
+}
+
+// RevocationSource streams the revocations made by any client of the
+// Identity Service. It is implemented by the Client, see VerifierConfig.
+type RevocationSource interface {
+	// WatchRevocations calls fn for every revocation until the stream breaks.
+	WatchRevocations(ctx context.Context, fn func(*pb.RevocationEvent)) error
+}
+
+// WatchRevocations calls fn for every revocation until the stream breaks.
+func (c *Client) WatchRevocations(ctx context.Context, fn func(*pb.RevocationEvent)) error {
+	stream, err := c.grpcsvc.WatchRevocations(ctx, &pb.WatchRevocationsRequest{})
+	if err != nil {
+		return err
+	}
+	for {
+		ev, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return errors.New("stream closed by server")
+		}
+		if err != nil {
+			return err
+		}
+		fn(ev)
+	}
+}
+
+// startRevocationWatch subscribes the cache to the revocations
+// made by any client of the Identity Service, until Close.
+func (c *Client) startRevocationWatch() {
+	ctx, cancel := context.WithCancel(context.Background())
+	c.stopWatch = cancel
+	c.watchDone = make(chan struct{})
+
+	go func() {
+		defer close(c.watchDone)
+		watchLoop(ctx, c.logger, "cached sessions expire by TTL only", func(ctx context.Context) error {
+			return c.WatchRevocations(ctx, c.applyRevocation)
+		})
+	}()
+}
+
+// watchLoop keeps the revocation stream open until the context is done,
+// reconnecting with a backoff. It gives up if the Identity Service does
+// not stream revocations, logging the consequence.
+func watchLoop(ctx context.Context, logger zerolog.Logger, unimplemented string, watch func(context.Context) error) {
+	backoff := time.Second
+	for {
+		start := time.Now()
+		err := watch(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if status.Code(err) == codes.Unimplemented {
+			logger.Warn().Msg("Identity Service does not stream revocations, " + unimplemented)
+			return
+		}
+		if time.Since(start) > time.Minute {
+			backoff = time.Second
+		}
+		logger.Warn().Err(err).Msgf("revocation stream interrupted, reconnecting in %s", backoff)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, 30*time.Second)
+	}
+}
+
+// applyRevocation drops the revoked sessions from the cache.
+func (c *Client) applyRevocation(ev *pb.RevocationEvent) {
+	switch t := ev.GetTarget().(type) {
+	case *pb.RevocationEvent_TokenSha256:
+		var key tokenKey
+		if len(t.TokenSha256) == len(key) {
+			copy(key[:], t.TokenSha256)
+			c.cache.revokeToken(key)
+		}
+	case *pb.RevocationEvent_UserId:
+		c.cache.revokeUser(t.UserId)
+	}
+}
+
+// revocationList remembers the revocations for the offline
+// verification, for as long as the revoked tokens may live.
+type revocationList struct {
+	retention time.Duration
+
+	mu     sync.Mutex
+	tokens map[tokenKey]time.Time
+	users  map[string]time.Time
+}
+
+func newRevocationList(retention time.Duration) *revocationList {
+	return &revocationList{
+		retention: retention,
+		tokens:    make(map[tokenKey]time.Time),
+		users:     make(map[string]time.Time),
+	}
+}
+
+func (l *revocationList) apply(ev *pb.RevocationEvent) {
+	now := time.Now()
+	at := now
+	if ev.GetRevokedAt() != nil {
+		at = ev.GetRevokedAt().AsTime()
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	switch t := ev.GetTarget().(type) {
+	case *pb.RevocationEvent_TokenSha256:
+		var key tokenKey
+		if len(t.TokenSha256) == len(key) {
+			copy(key[:], t.TokenSha256)
+			l.tokens[key] = at
+		}
+	case *pb.RevocationEvent_UserId:
+		if at.After(l.users[t.UserId]) {
+			l.users[t.UserId] = at
+		}
+	}
+	l.prune(now)
+}
+
+// revoked reports whether the token was revoked, or issued to
+// the user before all of the user sessions were revoked.
+func (l *revocationList) revoked(key tokenKey, userID string, issuedAt time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.tokens[key]; ok {
+		return true
+	}
+	at, ok := l.users[userID]
+	return ok && !issuedAt.After(at)
+}
+
+// prune forgets the revocations older than the retention,
+// the tokens they concern have expired since.
+func (l *revocationList) prune(now time.Time) {
+	cutoff := now.Add(-l.retention)
+	for key, at := range l.tokens {
+		if at.Before(cutoff) {
+			delete(l.tokens, key)
+		}
+	}
+	for user, at := range l.users {
+		if at.Before(cutoff) {
+			delete(l.users, user)
+		}
+	}
+}
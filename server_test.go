@@ -0,0 +1,57 @@
+package identity
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	pb "github.com/kodeart/identity-sdk-go/proto/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeIdentityService answers the calls with the configured functions,
+// everything else is Unimplemented.
+type fakeIdentityService struct {
+	pb.UnimplementedIdentityServiceServer
+
+	authenticate    func(context.Context, *pb.AuthenticateRequest) (*pb.AuthenticateResponse, error)
+	validateSession func(context.Context, *pb.ValidateSessionRequest) (*pb.ValidateSessionResponse, error)
+	refreshSession  func(context.Context, *pb.RefreshSessionRequest) (*pb.AuthenticateResponse, error)
+}
+
+func (f *fakeIdentityService) Authenticate(ctx context.Context, req *pb.AuthenticateRequest) (*pb.AuthenticateResponse, error) {
+	if f.authenticate == nil {
+		return nil, status.Error(codes.Unimplemented, "Authenticate")
+	}
+	return f.authenticate(ctx, req)
+}
+
+func (f *fakeIdentityService) ValidateSession(ctx context.Context, req *pb.ValidateSessionRequest) (*pb.ValidateSessionResponse, error) {
+	if f.validateSession == nil {
+		return nil, status.Error(codes.Unimplemented, "ValidateSession")
+	}
+	return f.validateSession(ctx, req)
+}
+
+func (f *fakeIdentityService) RefreshSession(ctx context.Context, req *pb.RefreshSessionRequest) (*pb.AuthenticateResponse, error) {
+	if f.refreshSession == nil {
+		return nil, status.Error(codes.Unimplemented, "RefreshSession")
+	}
+	return f.refreshSession(ctx, req)
+}
+
+// startServer serves the service on a loopback port until the test ends.
+func startServer(t *testing.T, svc pb.IdentityServiceServer, opts ...grpc.ServerOption) string {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := grpc.NewServer(opts...)
+	pb.RegisterIdentityServiceServer(s, svc)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+	return lis.Addr().String()
+}
@@ -0,0 +1,43 @@
+package identity
+
+import (
+	"context"
+
+	pb "github.com/kodeart/identity-sdk-go/proto/v1"
+)
+
+// GetTenantByID returns the tenant with the id.
+func (c *Client) GetTenantByID(ctx context.Context, id string) (*pb.Tenant, error) {
+	v := newValidator("GetTenantByID")
+	v.required("id", id)
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+	return c.grpcsvc.GetTenant(ctx, &pb.GetTenantRequest{
+		Identifier: &pb.GetTenantRequest_Id{Id: id},
+	})
+}
+
+// GetTenantBySlug returns the tenant with the slug.
+func (c *Client) GetTenantBySlug(ctx context.Context, slug string) (*pb.Tenant, error) {
+	v := newValidator("GetTenantBySlug")
+	v.slug("slug", slug)
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+	return c.grpcsvc.GetTenant(ctx, &pb.GetTenantRequest{
+		Identifier: &pb.GetTenantRequest_Slug{Slug: slug},
+	})
+}
+
+// CreateTenant creates a tenant. The slug is what users sign in with,
+// see AuthenticateWithCredentials, and must be a DNS label.
+func (c *Client) CreateTenant(ctx context.Context, name, slug string) (*pb.Tenant, error) {
+	v := newValidator("CreateTenant")
+	v.required("name", name)
+	v.slug("slug", slug)
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+	return c.grpcsvc.CreateTenant(ctx, &pb.CreateTenantRequest{Name: name, Slug: slug})
+}
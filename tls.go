@@ -0,0 +1,175 @@
+package identity
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc/credentials"
+)
+
+// MTLSConfig holds the PEM files used to establish a mutual TLS
+// connection with the Identity Service.
+type MTLSConfig struct {
+	// CAFile is the bundle used to verify the server certificate.
+	CAFile string
+	// CertFile and KeyFile are the client certificate and its private key.
+	CertFile string
+	KeyFile  string
+	// ServerName overrides the name used to verify the server certificate.
+	// Defaults to the authority of the channel.
+	ServerName string
+	// MinVersion defaults to TLS 1.2.
+	MinVersion uint16
+}
+
+// WithMTLS secures the channel with mutual TLS.
+//
+// The files are checked for changes before every handshake, so rotated
+// certificates are picked up by new connections without a restart.
+// When a reload fails the previously loaded certificates are kept.
+// WithMTLS takes precedence over WithTransportCredentials.
+func WithMTLS(cfg MTLSConfig) Option {
+	return func(o *options) {
+		o.mtls = &cfg
+	}
+}
+
+// reloadingCredentials delegates the handshake to TLS
+// credentials that are rebuilt whenever the files change.
+type reloadingCredentials struct {
+	cfg    MTLSConfig
+	logger zerolog.Logger
+
+	mu      sync.Mutex
+	stamp   fileStamp
+	current credentials.TransportCredentials
+}
+
+// fileStamp identifies one revision of the certificate files.
+type fileStamp [3]struct {
+	modTime time.Time
+	size    int64
+}
+
+func newReloadingCredentials(cfg MTLSConfig, logger zerolog.Logger) (*reloadingCredentials, error) {
+	if cfg.CAFile == "" || cfg.CertFile == "" || cfg.KeyFile == "" {
+		return nil, errors.New("identity: mTLS requires a CA, a certificate and a key file")
+	}
+	rc := &reloadingCredentials{cfg: cfg, logger: logger}
+	if _, err := rc.load(); err != nil {
+		return nil, err
+	}
+	return rc, nil
+}
+
+// load returns the current credentials, rebuilding them if any of the files changed.
+func (rc *reloadingCredentials) load() (credentials.TransportCredentials, error) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	stamp, err := rc.statFiles()
+	if err != nil {
+		return rc.current, err
+	}
+	if rc.current != nil && stamp == rc.stamp {
+		return rc.current, nil
+	}
+	tlsCfg, err := rc.tlsConfig()
+	if err != nil {
+		return rc.current, err
+	}
+	if rc.current != nil {
+		rc.logger.Info().Msgf("reloaded mTLS certificates from %s", rc.cfg.CertFile)
+	}
+	rc.current = credentials.NewTLS(tlsCfg)
+	rc.stamp = stamp
+	return rc.current, nil
+}
+
+func (rc *reloadingCredentials) statFiles() (fileStamp, error) {
+	var stamp fileStamp
+	for i, name := range []string{rc.cfg.CAFile, rc.cfg.CertFile, rc.cfg.KeyFile} {
+		fi, err := os.Stat(name)
+		if err != nil {
+			return stamp, fmt.Errorf("identity: %w", err)
+		}
+		stamp[i].modTime = fi.ModTime()
+		stamp[i].size = fi.Size()
+	}
+	return stamp, nil
+}
+
+func (rc *reloadingCredentials) tlsConfig() (*tls.Config, error) {
+	caPEM, err := os.ReadFile(rc.cfg.CAFile)
+	if err != nil {
+		return nil, fmt.Errorf("identity: %w", err)
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("identity: no certificates found in %s", rc.cfg.CAFile)
+	}
+	cert, err := tls.LoadX509KeyPair(rc.cfg.CertFile, rc.cfg.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("identity: %w", err)
+	}
+	minVersion := rc.cfg.MinVersion
+	if minVersion == 0 {
+		minVersion = tls.VersionTLS12
+	}
+	return &tls.Config{
+		RootCAs:      roots,
+		Certificates: []tls.Certificate{cert},
+		ServerName:   rc.cfg.ServerName,
+		MinVersion:   minVersion,
+	}, nil
+}
+
+func (rc *reloadingCredentials) ClientHandshake(ctx context.Context, authority string, conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	creds, err := rc.load()
+	if err != nil {
+		if creds == nil {
+			return nil, nil, err
+		}
+		rc.logger.Warn().Err(err).Msg("failed to reload mTLS certificates, using the previous ones")
+	}
+	return creds.ClientHandshake(ctx, authority, conn)
+}
+
+func (rc *reloadingCredentials) ServerHandshake(net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	return nil, nil, errors.New("identity: mTLS credentials are client-side only")
+}
+
+// Info reports the same fixed version as the gRPC TLS credentials,
+// the negotiated one is in the credentials.TLSInfo of the connection.
+func (rc *reloadingCredentials) Info() credentials.ProtocolInfo {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return credentials.ProtocolInfo{
+		SecurityProtocol: "tls",
+		SecurityVersion:  "1.2",
+		ServerName:       rc.cfg.ServerName,
+	}
+}
+
+func (rc *reloadingCredentials) Clone() credentials.TransportCredentials {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return &reloadingCredentials{cfg: rc.cfg, logger: rc.logger, stamp: rc.stamp, current: rc.current}
+}
+
+// OverrideServerName is deprecated in gRPC, but still part of the interface.
+func (rc *reloadingCredentials) OverrideServerName(serverName string) error {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.cfg.ServerName = serverName
+	rc.current = nil
+	return nil
+}
@@ -0,0 +1,203 @@
+package identity
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	pb "github.com/kodeart/identity-sdk-go/proto/v1"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+// testCA issues the certificates of a test.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+	key := newKey(t)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns the PEM certificate and key of a leaf named cn.
+func (ca *testCA) issue(t *testing.T, cn string, usage x509.ExtKeyUsage) (certPEM, keyPEM []byte) {
+	t.Helper()
+	key := newKey(t)
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func newKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// writeFile writes the file with a distinct modification
+// time, so the rotation is seen even within one clock tick.
+func writeFile(t *testing.T, name string, data []byte, mtime time.Time) {
+	t.Helper()
+	if err := os.WriteFile(name, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(name, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// startMTLSServer answers ValidateSession with the common name
+// of the client certificate as the user id.
+func startMTLSServer(t *testing.T, ca *testCA) string {
+	t.Helper()
+	certPEM, keyPEM := ca.issue(t, "identity-service", x509.ExtKeyUsageServerAuth)
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(ca.cert)
+	creds := credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    clientCAs,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	})
+	return startServer(t, &fakeIdentityService{
+		validateSession: func(ctx context.Context, _ *pb.ValidateSessionRequest) (*pb.ValidateSessionResponse, error) {
+			p, _ := peer.FromContext(ctx)
+			info := p.AuthInfo.(credentials.TLSInfo)
+			return &pb.ValidateSessionResponse{
+				Valid: true,
+				User:  &pb.User{Id: info.State.PeerCertificates[0].Subject.CommonName},
+			}, nil
+		},
+	}, grpc.Creds(creds))
+}
+
+func TestReloadingCredentialsRotation(t *testing.T) {
+	ca := newTestCA(t)
+	addr := startMTLSServer(t, ca)
+
+	dir := t.TempDir()
+	cfg := MTLSConfig{
+		CAFile:     filepath.Join(dir, "ca.pem"),
+		CertFile:   filepath.Join(dir, "client.pem"),
+		KeyFile:    filepath.Join(dir, "client-key.pem"),
+		ServerName: "localhost",
+	}
+	mtime := time.Now().Add(-time.Hour)
+	rotate := func(certPEM, keyPEM []byte) {
+		mtime = mtime.Add(time.Minute)
+		writeFile(t, cfg.CertFile, certPEM, mtime)
+		writeFile(t, cfg.KeyFile, keyPEM, mtime)
+	}
+	writeFile(t, cfg.CAFile, ca.pem, mtime)
+	rotate(ca.issue(t, "client-1", x509.ExtKeyUsageClientAuth))
+
+	rc, err := newReloadingCredentials(cfg, zerolog.Nop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// presented opens a new connection, hence a new handshake,
+	// and returns the common name of the client certificate.
+	presented := func() string {
+		t.Helper()
+		conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(rc))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		resp, err := pb.NewIdentityServiceClient(conn).ValidateSession(ctx, &pb.ValidateSessionRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.GetUser().GetId()
+	}
+
+	if got := presented(); got != "client-1" {
+		t.Fatalf("presented %q, want client-1", got)
+	}
+
+	rotate(ca.issue(t, "client-2", x509.ExtKeyUsageClientAuth))
+	if got := presented(); got != "client-2" {
+		t.Fatalf("after the rotation presented %q, want client-2", got)
+	}
+
+	// A certificate with the key of another one cannot be loaded.
+	certPEM, _ := ca.issue(t, "client-3", x509.ExtKeyUsageClientAuth)
+	_, keyPEM := ca.issue(t, "client-4", x509.ExtKeyUsageClientAuth)
+	rotate(certPEM, keyPEM)
+	if got := presented(); got != "client-2" {
+		t.Fatalf("after a broken rotation presented %q, want the previous client-2", got)
+	}
+}
+
+func TestReloadingCredentialsInfo(t *testing.T) {
+	want := credentials.NewTLS(&tls.Config{ServerName: "identity.internal"}).Info()
+	for _, minVersion := range []uint16{0, tls.VersionTLS12, tls.VersionTLS13} {
+		rc := &reloadingCredentials{cfg: MTLSConfig{ServerName: "identity.internal", MinVersion: minVersion}}
+		if got := rc.Info(); got != want {
+			t.Errorf("MinVersion %#x: Info %+v, want %+v as the gRPC TLS credentials", minVersion, got, want)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package identity
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	pb "github.com/kodeart/identity-sdk-go/proto/v1"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// refreshTimeout bounds a renewal shared by concurrent callers.
+	refreshTimeout = 10 * time.Second
+	// minRefreshBackoff and maxRefreshBackoff bound the delay
+	// between the renewals of a still valid token after a failure.
+	minRefreshBackoff = time.Second
+	maxRefreshBackoff = 30 * time.Second
+)
+
+// TokenSource holds a session and renews it with the refresh token
+// shortly before it expires. It is safe for concurrent use.
+type TokenSource struct {
+	client        *Client
+	refreshBefore time.Duration
+	jitter        time.Duration
+
+	inflight singleflight.Group
+
+	mu           sync.Mutex
+	accessToken  string
+	refreshToken string
+	expiresAt    time.Time
+	renewAt      time.Time
+	refreshing   bool
+	failures     int
+	retryAt      time.Time
+}
+
+// TokenSourceOption configures a TokenSource.
+type TokenSourceOption func(*TokenSource)
+
+// WithRefreshBefore sets how long before the expiry
+// the session is renewed, defaults to 1 minute.
+func WithRefreshBefore(d time.Duration) TokenSourceOption {
+	return func(ts *TokenSource) {
+		ts.refreshBefore = d
+	}
+}
+
+// WithRefreshJitter sets the maximum random delay subtracted from the
+// renewal time, so many workers do not refresh at once. Defaults to 15 seconds.
+func WithRefreshJitter(d time.Duration) TokenSourceOption {
+	return func(ts *TokenSource) {
+		ts.jitter = d
+	}
+}
+
+// NewTokenSource holds the session returned by one of the Authenticate calls.
+func NewTokenSource(client *Client, session *pb.AuthenticateResponse, opts ...TokenSourceOption) (*TokenSource, error) {
+	if session.GetAccessToken() == "" || session.GetRefreshToken() == "" {
+		return nil, errors.New("identity: token source requires an access and a refresh token")
+	}
+	ts := &TokenSource{
+		client:        client,
+		refreshBefore: time.Minute,
+		jitter:        15 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(ts)
+	}
+	ts.store(session, time.Now())
+	return ts, nil
+}
+
+// Token returns a valid access token, renewing the session if it is due.
+//
+// A single renewal runs at a time: while the current token has not
+// expired yet, the other callers get it without waiting. When the renewal
+// fails, the current token is returned and the renewal is retried with an
+// exponential backoff, or on the next call once the token has expired.
+func (ts *TokenSource) Token(ctx context.Context) (string, error) {
+	ts.mu.Lock()
+	now := time.Now()
+	token := ts.accessToken
+	valid := ts.expiresAt.IsZero() || now.Before(ts.expiresAt)
+	due := !ts.expiresAt.IsZero() && !now.Before(ts.renewAt) && (!valid || !now.Before(ts.retryAt))
+	busy := ts.refreshing
+	ts.mu.Unlock()
+
+	if !due || (valid && busy) {
+		return token, nil
+	}
+	if err := ts.renew(ctx); err != nil {
+		if valid {
+			return token, nil
+		}
+		return "", err
+	}
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	return ts.accessToken, nil
+}
+
+// Refresh renews the session immediately.
+func (ts *TokenSource) Refresh(ctx context.Context) error {
+	return ts.renew(ctx)
+}
+
+// ExpiresAt returns the expiry of the current access token.
+func (ts *TokenSource) ExpiresAt() time.Time {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	return ts.expiresAt
+}
+
+// renew refreshes the session once for the concurrent callers,
+// each of them waiting on its own context.
+func (ts *TokenSource) renew(ctx context.Context) error {
+	ch := ts.inflight.DoChan("refresh", func() (any, error) {
+		rctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), refreshTimeout)
+		defer cancel()
+		return nil, ts.refresh(rctx)
+	})
+	select {
+	case res := <-ch:
+		return res.Err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (ts *TokenSource) refresh(ctx context.Context) error {
+	ts.mu.Lock()
+	ts.refreshing = true
+	refreshToken := ts.refreshToken
+	ts.mu.Unlock()
+
+	resp, err := ts.client.RefreshSession(ctx, refreshToken)
+	if err == nil && resp.GetAccessToken() == "" {
+		err = errors.New("identity: refreshed session carries no access token")
+	}
+
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	now := time.Now()
+	ts.refreshing = false
+	if err != nil {
+		ts.failures++
+		backoff := min(minRefreshBackoff<<min(ts.failures-1, 5), maxRefreshBackoff)
+		ts.retryAt = now.Add(backoff)
+		ts.client.logger.Warn().Err(err).Str("refresh_token", redactToken(refreshToken)).Dur("retry_in", backoff).Msg("failed to refresh the session")
+		return err
+	}
+	ts.failures = 0
+	ts.retryAt = time.Time{}
+	ts.store(resp, now)
+	return nil
+}
+
+// store keeps the session and schedules its renewal. Refresh tokens are
+// kept across renewals unless the Identity Service rotates them.
+func (ts *TokenSource) store(session *pb.AuthenticateResponse, now time.Time) {
+	ts.accessToken = session.GetAccessToken()
+	if session.GetRefreshToken() != "" {
+		ts.refreshToken = session.GetRefreshToken()
+	}
+	if session.GetExpiresAt() == nil {
+		// Without an expiry the session is renewed only on demand.
+		ts.expiresAt, ts.renewAt = time.Time{}, time.Time{}
+		return
+	}
+	ts.expiresAt = session.GetExpiresAt().AsTime()
+	lifetime := ts.expiresAt.Sub(now)
+	before := ts.refreshBefore
+	if ts.jitter > 0 {
+		before += rand.N(ts.jitter)
+	}
+	if before > lifetime/2 {
+		// Short-lived tokens are renewed halfway through their lifetime.
+		before = lifetime / 2
+	}
+	ts.renewAt = ts.expiresAt.Add(-before)
+}
@@ -0,0 +1,144 @@
+package identity
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	pb "github.com/kodeart/identity-sdk-go/proto/v1"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// newTokenSourceClient serves RefreshSession with the function.
+func newTokenSourceClient(t *testing.T, refresh func(context.Context, *pb.RefreshSessionRequest) (*pb.AuthenticateResponse, error)) *Client {
+	t.Helper()
+	addr := startServer(t, &fakeIdentityService{refreshSession: refresh})
+	nop := zerolog.Nop()
+	c, err := NewClient(addr, WithoutRetry(), WithLogger(&nop))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+// dueSession expires soon, so it is due for renewal after halfway.
+func dueSession(access string) *pb.AuthenticateResponse {
+	return &pb.AuthenticateResponse{
+		AccessToken:  access,
+		RefreshToken: "refresh-1",
+		ExpiresAt:    timestamppb.New(time.Now().Add(100 * time.Millisecond)),
+	}
+}
+
+func TestTokenSourceRenews(t *testing.T) {
+	var got []string
+	c := newTokenSourceClient(t, func(_ context.Context, req *pb.RefreshSessionRequest) (*pb.AuthenticateResponse, error) {
+		got = append(got, req.GetRefreshToken())
+		return &pb.AuthenticateResponse{AccessToken: "access-2", ExpiresAt: timestamppb.New(time.Now().Add(time.Hour))}, nil
+	})
+	ts, err := NewTokenSource(c, dueSession("access-1"), WithRefreshJitter(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token, err := ts.Token(context.Background()); err != nil || token != "access-1" {
+		t.Fatalf("before renewal: got %q, %v", token, err)
+	}
+	time.Sleep(60 * time.Millisecond)
+	if token, err := ts.Token(context.Background()); err != nil || token != "access-2" {
+		t.Fatalf("after renewal: got %q, %v", token, err)
+	}
+	if err := ts.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	// The refresh token is kept since the Identity Service did not rotate it.
+	if len(got) != 2 || got[0] != "refresh-1" || got[1] != "refresh-1" {
+		t.Fatalf("refresh tokens sent: %v", got)
+	}
+}
+
+func TestTokenSourceSingleRenewal(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	c := newTokenSourceClient(t, func(ctx context.Context, _ *pb.RefreshSessionRequest) (*pb.AuthenticateResponse, error) {
+		calls.Add(1)
+		select {
+		case <-release:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		return &pb.AuthenticateResponse{AccessToken: "access-2", ExpiresAt: timestamppb.New(time.Now().Add(time.Hour))}, nil
+	})
+	session := dueSession("access-1")
+	session.ExpiresAt = timestamppb.New(time.Now().Add(2 * time.Second))
+	ts, err := NewTokenSource(c, session, WithRefreshJitter(0), WithRefreshBefore(2*time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Second)
+
+	first := make(chan string, 1)
+	go func() {
+		token, _ := ts.Token(context.Background())
+		first <- token
+	}()
+	for calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	// The renewal is in flight: the other callers get the valid token right away.
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if token, err := ts.Token(context.Background()); err != nil || token != "access-1" {
+				t.Errorf("concurrent caller: got %q, %v", token, err)
+			}
+		}()
+	}
+	wg.Wait()
+	close(release)
+	if token := <-first; token != "access-2" {
+		t.Fatalf("renewing caller: got %q", token)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("%d RefreshSession calls, want 1", n)
+	}
+}
+
+func TestTokenSourceBackoff(t *testing.T) {
+	var calls atomic.Int32
+	c := newTokenSourceClient(t, func(context.Context, *pb.RefreshSessionRequest) (*pb.AuthenticateResponse, error) {
+		calls.Add(1)
+		return nil, status.Error(codes.Unavailable, "down")
+	})
+	session := dueSession("access-1")
+	session.ExpiresAt = timestamppb.New(time.Now().Add(1500 * time.Millisecond))
+	ts, err := NewTokenSource(c, session, WithRefreshJitter(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(800 * time.Millisecond)
+
+	for range 5 {
+		if token, err := ts.Token(context.Background()); err != nil || token != "access-1" {
+			t.Fatalf("valid token after a failed renewal: got %q, %v", token, err)
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("%d RefreshSession calls within the backoff, want 1", n)
+	}
+
+	time.Sleep(time.Second)
+	if _, err := ts.Token(context.Background()); status.Code(err) != codes.Unavailable {
+		t.Fatalf("expired token: got %v, want the refresh error", err)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Fatalf("%d RefreshSession calls, want a retry once the token expired", n)
+	}
+}
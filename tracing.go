@@ -0,0 +1,126 @@
+package identity
+
+import (
+	"context"
+	"errors"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// TracerName is the instrumentation scope of the spans created by the SDK.
+const TracerName = "github.com/kodeart/identity-sdk-go"
+
+// Span attributes set by the SDK.
+const (
+	AttrTenant  = attribute.Key("identity.tenant")
+	AttrOutcome = attribute.Key("identity.outcome")
+	AttrCache   = attribute.Key("identity.cache")
+	AttrMethod  = attribute.Key("rpc.method")
+)
+
+// Span outcomes, the value of AttrOutcome.
+const (
+	OutcomeValid       = "valid"
+	OutcomeInvalid     = "invalid"
+	OutcomeUnavailable = "unavailable"
+	OutcomeError       = "error"
+)
+
+// WithTracerProvider creates spans for the Authenticate and ValidateSession
+// calls of the Client, and propagates the trace context to the Identity
+// Service through the gRPC metadata. Tests can pass an SDK provider
+// recording to tracetest.NewInMemoryExporter.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(o *options) {
+		o.tracerProvider = tp
+	}
+}
+
+// WithPropagator sets how the trace context is written
+// to the gRPC metadata, defaults to otel.GetTextMapPropagator.
+func WithPropagator(p propagation.TextMapPropagator) Option {
+	return func(o *options) {
+		o.propagator = p
+	}
+}
+
+func (o *options) tracer() trace.Tracer {
+	if o.tracerProvider == nil {
+		return noop.NewTracerProvider().Tracer(TracerName)
+	}
+	return o.tracerProvider.Tracer(TracerName)
+}
+
+// propagatingInterceptor injects the trace context of the call into its outgoing metadata.
+func propagatingInterceptor(p propagation.TextMapPropagator) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		md, _ := metadata.FromOutgoingContext(ctx)
+		md = md.Copy()
+		p.Inject(ctx, metadataCarrier(md))
+		return invoker(metadata.NewOutgoingContext(ctx, md), method, req, reply, cc, opts...)
+	}
+}
+
+func (o *options) propagatorOrGlobal() propagation.TextMapPropagator {
+	if o.propagator != nil {
+		return o.propagator
+	}
+	return otel.GetTextMapPropagator()
+}
+
+// metadataCarrier adapts gRPC metadata to a propagation.TextMapCarrier.
+type metadataCarrier metadata.MD
+
+func (mc metadataCarrier) Get(key string) string {
+	if v := metadata.MD(mc).Get(key); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+func (mc metadataCarrier) Set(key, value string) {
+	metadata.MD(mc).Set(key, value)
+}
+
+func (mc metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(mc))
+	for k := range mc {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// Outcome classifies the error of a session call as one of the span outcomes.
+func Outcome(err error) string {
+	switch {
+	case err == nil:
+		return OutcomeValid
+	case errors.Is(err, ErrInvalidSession), status.Code(err) == codes.Unauthenticated:
+		return OutcomeInvalid
+	case IsUnavailable(err):
+		return OutcomeUnavailable
+	default:
+		return OutcomeError
+	}
+}
+
+// endSpan records the outcome of the call on the span and ends it.
+// Invalid sessions are an expected outcome, not a span error.
+func endSpan(span trace.Span, err error) {
+	outcome := Outcome(err)
+	span.SetAttributes(AttrOutcome.String(outcome))
+	if outcome != OutcomeValid && outcome != OutcomeInvalid {
+		span.RecordError(err)
+		span.SetStatus(otelcodes.Error, outcome)
+	}
+	span.End()
+}
@@ -0,0 +1,118 @@
+package identity
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	pb "github.com/kodeart/identity-sdk-go/proto/v1"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/metadata"
+)
+
+// spanAttrs indexes the attributes of the recorded span.
+func spanAttrs(s tracetest.SpanStub) map[attribute.Key]string {
+	attrs := make(map[attribute.Key]string, len(s.Attributes))
+	for _, kv := range s.Attributes {
+		attrs[kv.Key] = kv.Value.Emit()
+	}
+	return attrs
+}
+
+func TestTracing(t *testing.T) {
+	var (
+		mu           sync.Mutex
+		traceparents = make(map[string]string)
+	)
+	record := func(ctx context.Context, method string) {
+		mu.Lock()
+		defer mu.Unlock()
+		if v := metadata.ValueFromIncomingContext(ctx, "traceparent"); len(v) > 0 {
+			traceparents[method] = v[0]
+		}
+	}
+	addr := startServer(t, &fakeIdentityService{
+		authenticate: func(ctx context.Context, _ *pb.AuthenticateRequest) (*pb.AuthenticateResponse, error) {
+			record(ctx, "Authenticate")
+			return &pb.AuthenticateResponse{AccessToken: "access"}, nil
+		},
+		validateSession: func(ctx context.Context, req *pb.ValidateSessionRequest) (*pb.ValidateSessionResponse, error) {
+			record(ctx, "ValidateSession")
+			if req.GetToken() != "access" {
+				return &pb.ValidateSessionResponse{Valid: false, Reason: "unknown token"}, nil
+			}
+			return &pb.ValidateSessionResponse{Valid: true, User: &pb.User{Id: "u1", TenantId: "t1"}}, nil
+		},
+	})
+	exp := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+	defer tp.Shutdown(context.Background())
+	c, err := NewClient(addr, WithoutRetry(), WithTracerProvider(tp), WithPropagator(propagation.TraceContext{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "request")
+	if _, err := c.AuthenticateWithCredentials(ctx, "acme", "ada@example.com", "secret"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.ValidateSession(ctx, "access"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.ValidateSession(ctx, "other"); err == nil {
+		t.Fatal("an unknown token was validated")
+	}
+	parent.End()
+
+	spans := exp.GetSpans()
+	if len(spans) != 4 {
+		t.Fatalf("%d spans recorded, want 4", len(spans))
+	}
+	want := []struct {
+		name  string
+		attrs map[attribute.Key]string
+	}{
+		{"identity.Authenticate", map[attribute.Key]string{
+			AttrMethod: pb.IdentityService_Authenticate_FullMethodName, AttrTenant: "acme", AttrOutcome: OutcomeValid,
+		}},
+		{"identity.ValidateSession", map[attribute.Key]string{
+			AttrMethod: pb.IdentityService_ValidateSession_FullMethodName, AttrTenant: "t1", AttrOutcome: OutcomeValid,
+		}},
+		{"identity.ValidateSession", map[attribute.Key]string{
+			AttrMethod: pb.IdentityService_ValidateSession_FullMethodName, AttrOutcome: OutcomeInvalid,
+		}},
+	}
+	for i, w := range want {
+		s := spans[i]
+		if s.Name != w.name || s.SpanKind != trace.SpanKindClient {
+			t.Errorf("span %d = %s (%s), want a client span %s", i, s.Name, s.SpanKind, w.name)
+		}
+		if s.Parent.SpanID() != parent.SpanContext().SpanID() {
+			t.Errorf("span %s is not a child of the request span", s.Name)
+		}
+		attrs := spanAttrs(s)
+		for k, v := range w.attrs {
+			if attrs[k] != v {
+				t.Errorf("span %s: %s = %q, want %q", s.Name, k, attrs[k], v)
+			}
+		}
+	}
+
+	// The trace context of each span reaches the Identity Service.
+	for method, span := range map[string]tracetest.SpanStub{"Authenticate": spans[0], "ValidateSession": spans[2]} {
+		got, ok := traceparents[method]
+		if !ok {
+			t.Errorf("%s: no traceparent in the incoming metadata", method)
+			continue
+		}
+		sc := span.SpanContext
+		if want := "00-" + sc.TraceID().String() + "-" + sc.SpanID().String() + "-01"; got != want {
+			t.Errorf("%s: traceparent = %q, want %q", method, got, want)
+		}
+	}
+}
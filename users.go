@@ -0,0 +1,46 @@
+package identity
+
+import (
+	"context"
+
+	pb "github.com/kodeart/identity-sdk-go/proto/v1"
+)
+
+// GetUser returns the user of the tenant.
+func (c *Client) GetUser(ctx context.Context, tenantID, userID string) (*pb.User, error) {
+	v := newValidator("GetUser")
+	v.required("tenant_id", tenantID)
+	v.required("id", userID)
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+	return c.grpcsvc.GetUser(ctx, &pb.GetUserRequest{Id: userID, TenantId: tenantID})
+}
+
+// CreateUser creates a user in the tenant of the request.
+// The password is optional for users signing in through a provider.
+func (c *Client) CreateUser(ctx context.Context, req *pb.CreateUserRequest) (*pb.User, error) {
+	v := newValidator("CreateUser")
+	if req == nil {
+		v.fail("request", "is required")
+		return nil, v.err()
+	}
+	v.email("email", req.GetEmail())
+	v.required("tenant_id", req.GetTenantId())
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+	return c.grpcsvc.CreateUser(ctx, req)
+}
+
+// UpdateUser starts a partial update of the user, sent by UserUpdate.Do.
+// Only the fields set on the builder are changed:
+//
+//	user, err := client.UpdateUser(id).
+//		SetDisplayName("Ada").
+//		MergeMetadata(map[string]any{"limits": map[string]any{"api": 100}}).
+//		DeleteMetadataKey("trial").
+//		Do(ctx)
+func (c *Client) UpdateUser(id string) *UserUpdate {
+	return &UserUpdate{client: c, id: id}
+}
@@ -0,0 +1,201 @@
+package identity
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+
+	pb "github.com/kodeart/identity-sdk-go/proto/v1"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// UserUpdate builds an UpdateUser request and its field mask,
+// so unset fields are left unchanged instead of being cleared.
+type UserUpdate struct {
+	client *Client
+	id     string
+
+	displayName string
+	// metadata holds the new values of the masked metadata paths,
+	// or the whole new metadata once replaceMetadata is set.
+	metadata        map[string]any
+	replaceMetadata bool
+	paths           []string
+	violations      []FieldViolation
+}
+
+// SetDisplayName sets the display name, an empty name clears it.
+func (u *UserUpdate) SetDisplayName(name string) *UserUpdate {
+	u.displayName = name
+	u.addPath("display_name")
+	return u
+}
+
+// ReplaceMetadata replaces the whole metadata, nil clears it.
+func (u *UserUpdate) ReplaceMetadata(metadata map[string]any) *UserUpdate {
+	u.metadata, _ = cloneValue(metadata).(map[string]any)
+	u.replaceMetadata = true
+	u.paths = slices.DeleteFunc(u.paths, func(p string) bool { return strings.HasPrefix(p, "metadata.") })
+	u.addPath("metadata")
+	return u
+}
+
+// MergeMetadata deep-merges the metadata into the current one: nested
+// objects are merged key by key, every other value replaces the current one.
+// An empty nested object changes nothing, see SetMetadataKey to clear one.
+func (u *UserUpdate) MergeMetadata(metadata map[string]any) *UserUpdate {
+	u.merge(nil, metadata)
+	return u
+}
+
+// SetMetadataKey replaces the value of the top-level metadata key,
+// nested objects included, e.g. an empty map clears the object.
+func (u *UserUpdate) SetMetadataKey(key string, value any) *UserUpdate {
+	u.set([]string{key}, value)
+	return u
+}
+
+// DeleteMetadataKey removes the top-level metadata key.
+func (u *UserUpdate) DeleteMetadataKey(key string) *UserUpdate {
+	if !u.validKey(key) {
+		return u
+	}
+	if !u.replaceMetadata {
+		u.addPath("metadata." + key)
+	}
+	delete(u.metadata, key)
+	return u
+}
+
+// Request returns the UpdateUser request, e.g. for logging
+// or to send it through another client.
+func (u *UserUpdate) Request() (*pb.UpdateUserRequest, error) {
+	v := newValidator("UpdateUser")
+	v.required("id", u.id)
+	for _, fv := range u.violations {
+		v.fail(fv.Field, fv.Description)
+	}
+	if len(u.paths) == 0 {
+		v.fail("update_mask", "no field to update")
+	}
+	var metadata *structpb.Struct
+	if u.metadata != nil {
+		var err error
+		if metadata, err = structpb.NewStruct(u.metadata); err != nil {
+			v.fail("metadata", err.Error())
+		}
+	}
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+	mask := &fieldmaskpb.FieldMask{Paths: u.paths}
+	mask.Normalize()
+	return &pb.UpdateUserRequest{
+		Id:          u.id,
+		DisplayName: u.displayName,
+		Metadata:    metadata,
+		UpdateMask:  mask,
+	}, nil
+}
+
+// Do validates and sends the update. The cached sessions
+// of the user are dropped, so the update is visible right away.
+func (u *UserUpdate) Do(ctx context.Context) (*pb.User, error) {
+	req, err := u.Request()
+	if err != nil {
+		return nil, err
+	}
+	user, err := u.client.grpcsvc.UpdateUser(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if u.client.cache != nil {
+		u.client.cache.revokeUser(u.id)
+	}
+	return user, nil
+}
+
+func (u *UserUpdate) merge(path []string, metadata map[string]any) {
+	for k, v := range metadata {
+		if nested, ok := v.(map[string]any); ok {
+			u.merge(append(path[:len(path):len(path)], k), nested)
+			continue
+		}
+		u.set(append(path[:len(path):len(path)], k), v)
+	}
+}
+
+// set stores the value at the metadata path and masks the path.
+func (u *UserUpdate) set(path []string, value any) {
+	for _, key := range path {
+		if !u.validKey(key) {
+			return
+		}
+	}
+	if !u.replaceMetadata {
+		u.addPath("metadata." + strings.Join(path, "."))
+	}
+	if u.metadata == nil {
+		u.metadata = make(map[string]any)
+	}
+	m := u.metadata
+	for _, key := range path[:len(path)-1] {
+		next, ok := m[key].(map[string]any)
+		if !ok {
+			next = make(map[string]any)
+			m[key] = next
+		}
+		m = next
+	}
+	m[path[len(path)-1]] = cloneValue(value)
+}
+
+// cloneValue deep-copies the JSON objects and arrays, so
+// the update does not share them with the caller.
+func cloneValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		if v == nil {
+			return v
+		}
+		m := make(map[string]any, len(v))
+		for k, e := range v {
+			m[k] = cloneValue(e)
+		}
+		return m
+	case []any:
+		if v == nil {
+			return v
+		}
+		s := make([]any, len(v))
+		for i, e := range v {
+			s[i] = cloneValue(e)
+		}
+		return s
+	default:
+		return v
+	}
+}
+
+// validKey rejects the keys that cannot be addressed by a field mask path.
+func (u *UserUpdate) validKey(key string) bool {
+	if key == "" || strings.ContainsAny(key, ".`") {
+		u.violations = append(u.violations, FieldViolation{
+			Field:       "metadata",
+			Description: "key " + strconv.Quote(key) + " cannot be updated partially, use ReplaceMetadata",
+		})
+		return false
+	}
+	return true
+}
+
+func (u *UserUpdate) addPath(path string) {
+	for _, p := range u.paths {
+		if p == path {
+			return
+		}
+	}
+	u.paths = append(u.paths, path)
+}
@@ -0,0 +1,25 @@
+package identity
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestUserUpdateMergeMetadata(t *testing.T) {
+	req, err := (&Client{}).UpdateUser("u1").
+		MergeMetadata(map[string]any{
+			"limits": map[string]any{},
+			"plan":   map[string]any{"tier": "pro"},
+		}).
+		SetMetadataKey("flags", map[string]any{}).
+		Request()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"metadata.flags", "metadata.plan.tier"}; !slices.Equal(req.GetUpdateMask().GetPaths(), want) {
+		t.Errorf("update mask = %v, want %v", req.GetUpdateMask().GetPaths(), want)
+	}
+	if _, ok := req.GetMetadata().GetFields()["limits"]; ok {
+		t.Error("an empty nested object must not be sent")
+	}
+}
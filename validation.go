@@ -0,0 +1,113 @@
+package identity
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"regexp"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrInvalidArgument is matched by every ValidationError,
+// e.g. errors.Is(err, identity.ErrInvalidArgument).
+var ErrInvalidArgument = errors.New("identity: invalid argument")
+
+// FieldViolation describes why a field of a request is invalid.
+type FieldViolation struct {
+	Field       string
+	Description string
+}
+
+// ValidationError is returned by the Client methods for a request
+// failing the input validation, before the Identity Service is called.
+type ValidationError struct {
+	// Method is the name of the Client method, e.g. "CreateUser".
+	Method     string
+	Violations []FieldViolation
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		parts[i] = v.Field + ": " + v.Description
+	}
+	return fmt.Sprintf("identity: invalid %s request: %s", e.Method, strings.Join(parts, "; "))
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrInvalidArgument
+}
+
+// GRPCStatus reports InvalidArgument with the violations as BadRequest
+// details, so AsProblem renders a 400 listing the invalid fields.
+func (e *ValidationError) GRPCStatus() *status.Status {
+	st := status.New(codes.InvalidArgument, e.Error())
+	br := &errdetails.BadRequest{}
+	for _, v := range e.Violations {
+		br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       v.Field,
+			Description: v.Description,
+		})
+	}
+	if detailed, err := st.WithDetails(br); err == nil {
+		return detailed
+	}
+	return st
+}
+
+// slugPattern is a DNS label: lowercase letters, digits and inner hyphens.
+var slugPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// validator collects the violations of a request.
+type validator struct {
+	method     string
+	violations []FieldViolation
+}
+
+func newValidator(method string) *validator {
+	return &validator{method: method}
+}
+
+func (v *validator) fail(field, description string) {
+	v.violations = append(v.violations, FieldViolation{Field: field, Description: description})
+}
+
+func (v *validator) required(field, value string) {
+	if strings.TrimSpace(value) == "" {
+		v.fail(field, "is required")
+	}
+}
+
+func (v *validator) email(field, value string) {
+	if value == "" {
+		v.fail(field, "is required")
+		return
+	}
+	// The domain must have a dot, net/mail accepts local domains.
+	addr, err := mail.ParseAddress(value)
+	if err != nil || addr.Address != value || !strings.Contains(value[strings.LastIndex(value, "@")+1:], ".") {
+		v.fail(field, "must be a valid email address")
+	}
+}
+
+func (v *validator) slug(field, value string) {
+	if value == "" {
+		v.fail(field, "is required")
+		return
+	}
+	if !slugPattern.MatchString(value) {
+		v.fail(field, "must be 1 to 63 lowercase letters, digits or inner hyphens")
+	}
+}
+
+// err returns the *ValidationError, or nil if the request is valid.
+func (v *validator) err() error {
+	if len(v.violations) == 0 {
+		return nil
+	}
+	return &ValidationError{Method: v.method, Violations: v.violations}
+}
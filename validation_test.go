@@ -0,0 +1,88 @@
+package identity
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	pb "github.com/kodeart/identity-sdk-go/proto/v1"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestValidation(t *testing.T) {
+	var calls atomic.Int32
+	addr := startServer(t, &fakeIdentityService{}, grpc.UnaryInterceptor(
+		func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			calls.Add(1)
+			return handler(ctx, req)
+		}))
+	c, err := NewClient(addr, WithoutRetry())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	ctx := context.Background()
+	tests := []struct {
+		name  string
+		call  func() error
+		field string
+	}{
+		{"CreateUser bad email", func() error {
+			_, err := c.CreateUser(ctx, &pb.CreateUserRequest{Email: "ada@localhost", TenantId: "t1"})
+			return err
+		}, "email"},
+		{"CreateUser no request", func() error {
+			_, err := c.CreateUser(ctx, nil)
+			return err
+		}, "request"},
+		{"GetUser empty tenant", func() error {
+			_, err := c.GetUser(ctx, " ", "u1")
+			return err
+		}, "tenant_id"},
+		{"CreateTenant bad slug", func() error {
+			_, err := c.CreateTenant(ctx, "Acme", "Acme_Corp")
+			return err
+		}, "slug"},
+		{"GetTenantBySlug trailing hyphen", func() error {
+			_, err := c.GetTenantBySlug(ctx, "acme-")
+			return err
+		}, "slug"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call()
+			var ve *ValidationError
+			if !errors.As(err, &ve) || !errors.Is(err, ErrInvalidArgument) {
+				t.Fatalf("got %v, want a *ValidationError", err)
+			}
+			if len(ve.Violations) != 1 || ve.Violations[0].Field != tt.field {
+				t.Fatalf("violations = %+v, want one on %s", ve.Violations, tt.field)
+			}
+			st := status.Convert(err)
+			if st.Code() != codes.InvalidArgument {
+				t.Fatalf("code = %s, want InvalidArgument", st.Code())
+			}
+			details := st.Details()
+			if len(details) != 1 {
+				t.Fatalf("details = %v, want a BadRequest", details)
+			}
+			br, ok := details[0].(*errdetails.BadRequest)
+			if !ok || len(br.GetFieldViolations()) != 1 || br.GetFieldViolations()[0].GetField() != tt.field {
+				t.Fatalf("details = %v, want a BadRequest on %s", details, tt.field)
+			}
+		})
+	}
+	if n := calls.Load(); n != 0 {
+		t.Fatalf("%d calls of the Identity Service, want none", n)
+	}
+
+	// A valid request reaches the Identity Service.
+	if _, err := c.CreateTenant(ctx, "Acme", "acme"); status.Code(err) != codes.Unimplemented || calls.Load() != 1 {
+		t.Fatalf("valid request: got %v after %d calls", err, calls.Load())
+	}
+}
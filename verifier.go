@@ -0,0 +1,510 @@
+package identity
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	pb "github.com/kodeart/identity-sdk-go/proto/v1"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// ErrUnverifiable is returned by the Verifier when it cannot decide
+// on the token locally, e.g. the token is not a JWT, it is signed by an
+// unknown key or the key set is not available. The token should
+// then be validated by the Identity Service.
+var ErrUnverifiable = errors.New("identity: token cannot be verified locally")
+
+// VerifierConfig configures the offline verification of session tokens.
+type VerifierConfig struct {
+	// Keys provides the key set, e.g. JWKSURL, JWKSFile or the Client itself.
+	Keys KeySource
+	// Issuer is the expected "iss" claim. Empty skips the check.
+	Issuer string
+	// Audience lists the accepted "aud" values, the token must
+	// contain at least one of them. Empty skips the check.
+	Audience []string
+	// TenantClaim is the claim holding the tenant id, defaults to "tenant_id".
+	// Tokens without a tenant are rejected.
+	TenantClaim string
+	// Tenants restricts the accepted tenant ids. Empty accepts any tenant.
+	Tenants []string
+	// Leeway tolerates clock skew on "exp" and "nbf", defaults to 30 seconds.
+	Leeway time.Duration
+	// RefreshInterval is how often the key set is reloaded
+	// in background, defaults to 5 minutes.
+	RefreshInterval time.Duration
+	// Revocations streams the revoked sessions, usually the Client.
+	// Revoked tokens, and the tokens of users whose sessions were all
+	// revoked, are then rejected offline once the event is received.
+	Revocations RevocationSource
+	// MaxTokenLifetime is the longest "exp" - "iat" span verified offline,
+	// longer lived tokens and tokens without "iat" are left to the fallback
+	// of LocalFirst. It bounds how long a revoked token missed by the
+	// Verifier stays usable. Defaults to 15 minutes, or 24 hours with
+	// Revocations; a negative value disables the check.
+	MaxTokenLifetime time.Duration
+	// Logger defaults to the global zerolog logger, see NewSlogLogger for slog.
+	Logger *zerolog.Logger
+}
+
+// Claims are the verified claims of a session token.
+type Claims struct {
+	Issuer      string
+	Subject     string
+	Audience    []string
+	ExpiresAt   time.Time
+	NotBefore   time.Time
+	IssuedAt    time.Time
+	ID          string
+	TenantID    string
+	TenantSlug  string
+	Email       string
+	Name        string
+	SessionID   string
+	Scopes      []string
+	Roles       []string
+	Permissions []string
+	// Raw holds every claim of the token, including the ones above.
+	Raw map[string]any
+}
+
+// Verifier checks session tokens against a JSON Web Key Set,
+// without a round-trip to the Identity Service.
+type Verifier struct {
+	cfg VerifierConfig
+
+	mu   sync.RWMutex
+	keys []publicKey
+
+	refreshMu   sync.Mutex
+	lastAttempt time.Time // guarded by refreshMu
+
+	closeOnce sync.Once
+	stop      chan struct{}
+	done      chan struct{}
+
+	revoked   *revocationList
+	stopWatch context.CancelFunc
+	watchDone chan struct{}
+}
+
+// minRefreshInterval limits the key set reloads triggered by unknown key ids.
+const minRefreshInterval = 10 * time.Second
+
+// NewVerifier loads the key set and starts reloading it in background.
+// Call Close to stop the background rotation.
+func NewVerifier(ctx context.Context, cfg VerifierConfig) (*Verifier, error) {
+	if cfg.Keys == nil {
+		return nil, errors.New("identity: verifier requires a key source")
+	}
+	if cfg.TenantClaim == "" {
+		cfg.TenantClaim = "tenant_id"
+	}
+	if cfg.Leeway == 0 {
+		cfg.Leeway = 30 * time.Second
+	}
+	if cfg.RefreshInterval <= 0 {
+		cfg.RefreshInterval = 5 * time.Minute
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = &log.Logger
+	}
+	if cfg.MaxTokenLifetime == 0 {
+		cfg.MaxTokenLifetime = 15 * time.Minute
+		if cfg.Revocations != nil {
+			cfg.MaxTokenLifetime = 24 * time.Hour
+		}
+	}
+	v := &Verifier{
+		cfg:  cfg,
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	if err := v.refresh(ctx); err != nil {
+		return nil, err
+	}
+	go v.rotate()
+	if cfg.Revocations != nil {
+		v.startRevocationWatch()
+	}
+	return v, nil
+}
+
+// Close stops the background key rotation and revocation watch.
+func (v *Verifier) Close() error {
+	v.closeOnce.Do(func() {
+		close(v.stop)
+		if v.stopWatch != nil {
+			v.stopWatch()
+		}
+	})
+	<-v.done
+	if v.watchDone != nil {
+		<-v.watchDone
+	}
+	return nil
+}
+
+// startRevocationWatch records the revocations of cfg.Revocations until Close.
+func (v *Verifier) startRevocationWatch() {
+	retention := 24 * time.Hour
+	if v.cfg.MaxTokenLifetime > 0 {
+		retention = v.cfg.MaxTokenLifetime
+	}
+	v.revoked = newRevocationList(retention + v.cfg.Leeway)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	v.stopWatch = cancel
+	v.watchDone = make(chan struct{})
+	go func() {
+		defer close(v.watchDone)
+		watchLoop(ctx, *v.cfg.Logger, "revoked tokens pass the offline verification until they expire", func(ctx context.Context) error {
+			return v.cfg.Revocations.WatchRevocations(ctx, v.revoked.apply)
+		})
+	}()
+}
+
+func (v *Verifier) rotate() {
+	defer close(v.done)
+
+	ticker := time.NewTicker(v.cfg.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-v.stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			if err := v.refresh(ctx); err != nil {
+				v.cfg.Logger.Warn().Err(err).Msg("failed to refresh the JWKS, keeping the previous keys")
+			}
+			cancel()
+		}
+	}
+}
+
+// refresh reloads the key set, replacing the current keys only on success.
+func (v *Verifier) refresh(ctx context.Context) error {
+	v.refreshMu.Lock()
+	defer v.refreshMu.Unlock()
+	return v.reload(ctx)
+}
+
+// reload fetches the key set, refreshMu must be held.
+func (v *Verifier) reload(ctx context.Context) error {
+	v.lastAttempt = time.Now()
+	data, err := v.cfg.Keys.FetchJWKS(ctx)
+	if err != nil {
+		return err
+	}
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return err
+	}
+	v.mu.Lock()
+	v.keys = keys
+	v.mu.Unlock()
+	return nil
+}
+
+// lookup returns the keys matching the key id and algorithm of the token.
+// An unknown key id triggers a rate limited reload of the key set,
+// failed attempts included. Concurrent tokens wait on the same reload.
+func (v *Verifier) lookup(ctx context.Context, kid, alg string) []publicKey {
+	if keys := v.match(kid, alg); len(keys) > 0 || kid == "" {
+		return keys
+	}
+	v.refreshMu.Lock()
+	if time.Since(v.lastAttempt) > minRefreshInterval {
+		if err := v.reload(ctx); err != nil {
+			v.cfg.Logger.Warn().Err(err).Msg("failed to refresh the JWKS")
+		}
+	}
+	v.refreshMu.Unlock()
+	return v.match(kid, alg)
+}
+
+func (v *Verifier) match(kid, alg string) []publicKey {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	var keys []publicKey
+	for _, k := range v.keys {
+		if kid != "" && k.kid != kid {
+			continue
+		}
+		if k.alg != "" && k.alg != alg {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// Verify checks the signature and the claims of the token.
+//
+// Tokens that are definitely not valid, e.g. expired or with a bad
+// signature, are reported as an *InvalidSessionError. Tokens the
+// Verifier cannot decide on are reported as ErrUnverifiable.
+func (v *Verifier) Verify(ctx context.Context, token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: not a JWT", ErrUnverifiable)
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("%w: malformed header", ErrUnverifiable)
+	}
+	hash, ok := signingHashes[header.Alg]
+	if !ok {
+		return nil, fmt.Errorf("%w: unsupported algorithm %q", ErrUnverifiable, header.Alg)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, &InvalidSessionError{Reason: "malformed signature"}
+	}
+	keys := v.lookup(ctx, header.Kid, header.Alg)
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%w: unknown key %q", ErrUnverifiable, header.Kid)
+	}
+	signed := []byte(parts[0] + "." + parts[1])
+	if !slices.ContainsFunc(keys, func(k publicKey) bool {
+		return verifySignature(header.Alg, hash, k.key, signed, sig)
+	}) {
+		return nil, &InvalidSessionError{Reason: "invalid signature"}
+	}
+
+	var raw map[string]any
+	if err := decodeSegment(parts[1], &raw); err != nil {
+		return nil, &InvalidSessionError{Reason: "malformed claims"}
+	}
+	claims, err := parseClaims(raw, v.cfg.TenantClaim)
+	if err != nil {
+		return nil, err
+	}
+	if err := v.validate(claims, time.Now()); err != nil {
+		return nil, err
+	}
+	if v.revoked != nil && v.revoked.revoked(keyOf(token), claims.Subject, claims.IssuedAt) {
+		return nil, &InvalidSessionError{Reason: "session revoked"}
+	}
+	return claims, nil
+}
+
+// ValidateSession verifies the token offline and converts its claims
+// to a SessionResult. It never calls the Identity Service.
+func (v *Verifier) ValidateSession(ctx context.Context, token string) (*SessionResult, error) {
+	claims, err := v.Verify(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	return &SessionResult{
+		Valid:     true,
+		User:      claims.User(),
+		ExpiresAt: claims.ExpiresAt,
+		SessionID: claims.SessionID,
+		Scopes:    claims.Scopes,
+	}, nil
+}
+
+func (v *Verifier) validate(c *Claims, now time.Time) error {
+	if c.ExpiresAt.IsZero() {
+		return &InvalidSessionError{Reason: "missing exp claim"}
+	}
+	if !now.Before(c.ExpiresAt.Add(v.cfg.Leeway)) {
+		return &InvalidSessionError{Reason: "session expired"}
+	}
+	if !c.NotBefore.IsZero() && now.Add(v.cfg.Leeway).Before(c.NotBefore) {
+		return &InvalidSessionError{Reason: "session not valid yet"}
+	}
+	if v.cfg.Issuer != "" && c.Issuer != v.cfg.Issuer {
+		return &InvalidSessionError{Reason: "unexpected issuer"}
+	}
+	if len(v.cfg.Audience) > 0 && !slices.ContainsFunc(c.Audience, func(aud string) bool {
+		return slices.Contains(v.cfg.Audience, aud)
+	}) {
+		return &InvalidSessionError{Reason: "unexpected audience"}
+	}
+	if c.TenantID == "" {
+		return &InvalidSessionError{Reason: "missing tenant claim"}
+	}
+	if len(v.cfg.Tenants) > 0 && !slices.Contains(v.cfg.Tenants, c.TenantID) {
+		return &InvalidSessionError{Reason: "unexpected tenant"}
+	}
+	if max := v.cfg.MaxTokenLifetime; max > 0 && (c.IssuedAt.IsZero() || c.ExpiresAt.Sub(c.IssuedAt) > max) {
+		return fmt.Errorf("%w: token lifetime exceeds %s", ErrUnverifiable, max)
+	}
+	return nil
+}
+
+// User builds the user context carried by the token.
+// Only the fields present in the claims are populated.
+func (c *Claims) User() *pb.User {
+	return &pb.User{
+		Id:          c.Subject,
+		Email:       c.Email,
+		TenantId:    c.TenantID,
+		TenantSlug:  c.TenantSlug,
+		DisplayName: c.Name,
+		Roles:       c.Roles,
+		Permissions: c.Permissions,
+	}
+}
+
+func parseClaims(raw map[string]any, tenantClaim string) (*Claims, error) {
+	c := &Claims{Raw: raw}
+	var err error
+	str := func(name string) string {
+		s, _ := raw[name].(string)
+		return s
+	}
+	c.Issuer = str("iss")
+	c.Subject = str("sub")
+	c.ID = str("jti")
+	c.TenantID = str(tenantClaim)
+	c.Email = str("email")
+	c.Name = str("name")
+	c.TenantSlug = str("tenant_slug")
+	c.SessionID = str("sid")
+	c.Scopes = strings.Fields(str("scope"))
+	if len(c.Scopes) == 0 {
+		c.Scopes = stringList(raw["scp"])
+	}
+	c.Roles = stringList(raw["roles"])
+	c.Permissions = stringList(raw["permissions"])
+
+	c.Audience = stringList(raw["aud"])
+	for name, dst := range map[string]*time.Time{"exp": &c.ExpiresAt, "nbf": &c.NotBefore, "iat": &c.IssuedAt} {
+		if *dst, err = numericDate(raw[name]); err != nil {
+			return nil, &InvalidSessionError{Reason: "malformed " + name + " claim"}
+		}
+	}
+	return c, nil
+}
+
+// stringList converts a claim holding a string or an array of strings.
+func stringList(v any) []string {
+	switch v := v.(type) {
+	case string:
+		return []string{v}
+	case []any:
+		list := make([]string, 0, len(v))
+		for _, s := range v {
+			if s, ok := s.(string); ok {
+				list = append(list, s)
+			}
+		}
+		return list
+	}
+	return nil
+}
+
+// numericDate converts a JWT NumericDate claim, absent claims are the zero time.
+func numericDate(v any) (time.Time, error) {
+	switch n := v.(type) {
+	case nil:
+		return time.Time{}, nil
+	case json.Number:
+		f, err := n.Float64()
+		if err != nil {
+			return time.Time{}, err
+		}
+		sec := int64(f)
+		return time.Unix(sec, int64((f-float64(sec))*1e9)), nil
+	default:
+		return time.Time{}, fmt.Errorf("unexpected type %T", v)
+	}
+}
+
+func decodeSegment(seg string, v any) error {
+	b, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+// signingHashes lists the supported asymmetric algorithms. Symmetric
+// algorithms and "none" are deliberately not supported.
+var signingHashes = map[string]crypto.Hash{
+	"RS256": crypto.SHA256, "RS384": crypto.SHA384, "RS512": crypto.SHA512,
+	"PS256": crypto.SHA256, "PS384": crypto.SHA384, "PS512": crypto.SHA512,
+	"ES256": crypto.SHA256, "ES384": crypto.SHA384, "ES512": crypto.SHA512,
+	"EdDSA": 0,
+}
+
+func verifySignature(alg string, hash crypto.Hash, key crypto.PublicKey, signed, sig []byte) bool {
+	if alg == "EdDSA" {
+		pub, ok := key.(ed25519.PublicKey)
+		return ok && ed25519.Verify(pub, signed, sig)
+	}
+	h := hash.New()
+	h.Write(signed)
+	digest := h.Sum(nil)
+
+	switch alg[:2] {
+	case "RS":
+		pub, ok := key.(*rsa.PublicKey)
+		return ok && rsa.VerifyPKCS1v15(pub, hash, digest, sig) == nil
+	case "PS":
+		pub, ok := key.(*rsa.PublicKey)
+		return ok && rsa.VerifyPSS(pub, hash, digest, sig, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}) == nil
+	case "ES":
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return false
+		}
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		if len(sig) != 2*size {
+			return false
+		}
+		r := new(big.Int).SetBytes(sig[:size])
+		s := new(big.Int).SetBytes(sig[size:])
+		return ecdsa.Verify(pub, digest, r, s)
+	}
+	return false
+}
+
+// LocalFirst validates tokens with the Verifier and falls back to the
+// fallback validator, usually the Client, when the Verifier cannot decide.
+// Tokens the Verifier rejects are not sent to the fallback.
+//
+// A token revoked while it is valid passes the Verifier until its
+// revocation is received from VerifierConfig.Revocations, or at most
+// for VerifierConfig.MaxTokenLifetime without it.
+func LocalFirst(v *Verifier, fallback SessionValidator) SessionValidator {
+	return localFirst{verifier: v, fallback: fallback}
+}
+
+type localFirst struct {
+	verifier *Verifier
+	fallback SessionValidator
+}
+
+func (lf localFirst) ValidateSession(ctx context.Context, token string) (*SessionResult, error) {
+	res, err := lf.verifier.ValidateSession(ctx, token)
+	if errors.Is(err, ErrUnverifiable) {
+		return lf.fallback.ValidateSession(ctx, token)
+	}
+	return res, err
+}
@@ -0,0 +1,345 @@
+package identity
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	pb "github.com/kodeart/identity-sdk-go/proto/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type staticKeys []byte
+
+func (k staticKeys) FetchJWKS(context.Context) ([]byte, error) { return k, nil }
+
+// chanRevocations feeds the events of the channel to the Verifier.
+type chanRevocations chan *pb.RevocationEvent
+
+func (c chanRevocations) WatchRevocations(ctx context.Context, fn func(*pb.RevocationEvent)) error {
+	for {
+		select {
+		case ev := <-c:
+			fn(ev)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+type testSigner struct {
+	key ed25519.PrivateKey
+}
+
+func newTestSigner(t *testing.T) (*testSigner, KeySource) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwks, err := json.Marshal(map[string]any{"keys": []map[string]string{{
+		"kty": "OKP", "crv": "Ed25519", "kid": "k1", "alg": "EdDSA",
+		"x": base64.RawURLEncoding.EncodeToString(pub),
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testSigner{key: priv}, staticKeys(jwks)
+}
+
+func (s *testSigner) sign(t *testing.T, claims map[string]any) string {
+	t.Helper()
+	return s.signWith(t, map[string]string{"alg": "EdDSA", "kid": "k1", "typ": "JWT"}, claims)
+}
+
+func (s *testSigner) signWith(t *testing.T, header map[string]string, claims map[string]any) string {
+	t.Helper()
+	enc := func(v any) string {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+	signed := enc(header) + "." + enc(claims)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(ed25519.Sign(s.key, []byte(signed)))
+}
+
+func claimsFor(sub string, iat time.Time, lifetime time.Duration) map[string]any {
+	return map[string]any{
+		"sub":       sub,
+		"tenant_id": "t1",
+		"iat":       iat.Unix(),
+		"exp":       iat.Add(lifetime).Unix(),
+	}
+}
+
+func TestVerifierMaxTokenLifetime(t *testing.T) {
+	signer, keys := newTestSigner(t)
+	v, err := NewVerifier(context.Background(), VerifierConfig{Keys: keys})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer v.Close()
+
+	now := time.Now()
+	if _, err := v.Verify(context.Background(), signer.sign(t, claimsFor("u1", now, 10*time.Minute))); err != nil {
+		t.Fatalf("short lived token: %v", err)
+	}
+	if _, err := v.Verify(context.Background(), signer.sign(t, claimsFor("u1", now, time.Hour))); !errors.Is(err, ErrUnverifiable) {
+		t.Fatalf("long lived token: got %v, want ErrUnverifiable", err)
+	}
+	noIat := claimsFor("u1", now, 10*time.Minute)
+	delete(noIat, "iat")
+	if _, err := v.Verify(context.Background(), signer.sign(t, noIat)); !errors.Is(err, ErrUnverifiable) {
+		t.Fatalf("token without iat: got %v, want ErrUnverifiable", err)
+	}
+}
+
+func TestVerifierRevocations(t *testing.T) {
+	signer, keys := newTestSigner(t)
+	events := make(chanRevocations)
+	v, err := NewVerifier(context.Background(), VerifierConfig{Keys: keys, Revocations: events})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer v.Close()
+
+	now := time.Now().Truncate(time.Second)
+	revokedToken := signer.sign(t, claimsFor("u1", now, time.Hour))
+	otherToken := signer.sign(t, claimsFor("u1", now.Add(-time.Second), time.Hour))
+	userToken := signer.sign(t, claimsFor("u2", now.Add(-time.Minute), time.Hour))
+	for _, token := range []string{revokedToken, otherToken, userToken} {
+		if _, err := v.Verify(context.Background(), token); err != nil {
+			t.Fatalf("before revocation: %v", err)
+		}
+	}
+
+	key := keyOf(revokedToken)
+	events <- &pb.RevocationEvent{Target: &pb.RevocationEvent_TokenSha256{TokenSha256: key[:]}}
+	events <- &pb.RevocationEvent{
+		Target:    &pb.RevocationEvent_UserId{UserId: "u2"},
+		RevokedAt: timestamppb.New(now),
+	}
+	// The unbuffered sends return once the events are read, this one
+	// once the previous events are applied.
+	events <- &pb.RevocationEvent{Target: &pb.RevocationEvent_UserId{UserId: "u3"}}
+
+	var invalid *InvalidSessionError
+	if _, err := v.Verify(context.Background(), revokedToken); !errors.As(err, &invalid) {
+		t.Fatalf("revoked token: got %v, want InvalidSessionError", err)
+	}
+	if _, err := v.Verify(context.Background(), otherToken); err != nil {
+		t.Fatalf("token not revoked: %v", err)
+	}
+	if _, err := v.Verify(context.Background(), userToken); !errors.As(err, &invalid) {
+		t.Fatalf("token of revoked user: got %v, want InvalidSessionError", err)
+	}
+	newToken := signer.sign(t, claimsFor("u2", now.Add(time.Second), time.Hour))
+	if _, err := v.Verify(context.Background(), newToken); err != nil {
+		t.Fatalf("token issued after the revocation: %v", err)
+	}
+}
+
+func TestVerifierClaims(t *testing.T) {
+	signer, keys := newTestSigner(t)
+	v, err := NewVerifier(context.Background(), VerifierConfig{
+		Keys:     keys,
+		Issuer:   "https://id.example.com",
+		Audience: []string{"api"},
+		Tenants:  []string{"t1"},
+		Leeway:   time.Minute,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer v.Close()
+
+	now := time.Now()
+	valid := func() map[string]any {
+		c := claimsFor("u1", now.Add(-time.Minute), 10*time.Minute)
+		c["iss"] = "https://id.example.com"
+		c["aud"] = []string{"other", "api"}
+		c["scope"] = "openid profile"
+		return c
+	}
+	tests := []struct {
+		name   string
+		change func(map[string]any)
+		reason string
+	}{
+		{"valid", func(map[string]any) {}, ""},
+		{"expired", func(c map[string]any) {
+			c["iat"], c["exp"] = now.Add(-10*time.Minute).Unix(), now.Add(-2*time.Minute).Unix()
+		}, "session expired"},
+		{"expired within leeway", func(c map[string]any) {
+			c["iat"], c["exp"] = now.Add(-10*time.Minute).Unix(), now.Add(-30*time.Second).Unix()
+		}, ""},
+		{"missing exp", func(c map[string]any) { delete(c, "exp") }, "missing exp claim"},
+		{"malformed exp", func(c map[string]any) { c["exp"] = "tomorrow" }, "malformed exp claim"},
+		{"not valid yet", func(c map[string]any) { c["nbf"] = now.Add(2 * time.Minute).Unix() }, "session not valid yet"},
+		{"nbf within leeway", func(c map[string]any) { c["nbf"] = now.Add(30 * time.Second).Unix() }, ""},
+		{"unexpected issuer", func(c map[string]any) { c["iss"] = "https://evil.example.com" }, "unexpected issuer"},
+		{"unexpected audience", func(c map[string]any) { c["aud"] = "other" }, "unexpected audience"},
+		{"missing audience", func(c map[string]any) { delete(c, "aud") }, "unexpected audience"},
+		{"missing tenant", func(c map[string]any) { delete(c, "tenant_id") }, "missing tenant claim"},
+		{"unexpected tenant", func(c map[string]any) { c["tenant_id"] = "t2" }, "unexpected tenant"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := valid()
+			tt.change(c)
+			claims, err := v.Verify(context.Background(), signer.sign(t, c))
+			if tt.reason == "" {
+				if err != nil {
+					t.Fatalf("got %v, want a valid token", err)
+				}
+				if claims.Subject != "u1" || claims.TenantID != "t1" || len(claims.Scopes) != 2 {
+					t.Errorf("claims = %+v", claims)
+				}
+				return
+			}
+			var invalid *InvalidSessionError
+			if !errors.As(err, &invalid) || invalid.Reason != tt.reason {
+				t.Fatalf("got %v, want InvalidSessionError %q", err, tt.reason)
+			}
+		})
+	}
+}
+
+func TestVerifierSignature(t *testing.T) {
+	signer, keys := newTestSigner(t)
+	other, _ := newTestSigner(t)
+	v, err := NewVerifier(context.Background(), VerifierConfig{Keys: keys})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer v.Close()
+
+	claims := claimsFor("u1", time.Now(), 10*time.Minute)
+	token := signer.sign(t, claims)
+	tampered := token[:len(token)-4] + "AAAA"
+	if token[len(token)-4:] == "AAAA" {
+		tampered = token[:len(token)-4] + "BBBB"
+	}
+	tests := []struct {
+		name         string
+		token        string
+		unverifiable bool
+		reason       string
+	}{
+		{"other key", other.sign(t, claims), false, "invalid signature"},
+		{"tampered signature", tampered, false, "invalid signature"},
+		{"malformed signature", token[:strings.LastIndex(token, ".")] + ".!!", false, "malformed signature"},
+		{"not a JWT", "garbage", true, ""},
+		{"malformed header", "a.b.c", true, ""},
+		{"none algorithm", signer.signWith(t, map[string]string{"alg": "none", "kid": "k1"}, claims), true, ""},
+		{"symmetric algorithm", signer.signWith(t, map[string]string{"alg": "HS256", "kid": "k1"}, claims), true, ""},
+		{"algorithm of another key type", signer.signWith(t, map[string]string{"alg": "ES256", "kid": "k1"}, claims), true, ""},
+		{"unknown key", signer.signWith(t, map[string]string{"alg": "EdDSA", "kid": "k2"}, claims), true, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := v.Verify(context.Background(), tt.token)
+			if tt.unverifiable {
+				if !errors.Is(err, ErrUnverifiable) {
+					t.Fatalf("got %v, want ErrUnverifiable", err)
+				}
+				return
+			}
+			var invalid *InvalidSessionError
+			if !errors.As(err, &invalid) || invalid.Reason != tt.reason {
+				t.Fatalf("got %v, want InvalidSessionError %q", err, tt.reason)
+			}
+		})
+	}
+}
+
+// countingKeys counts the key set fetches.
+type countingKeys struct {
+	KeySource
+	fetches atomic.Int32
+}
+
+func (k *countingKeys) FetchJWKS(ctx context.Context) ([]byte, error) {
+	k.fetches.Add(1)
+	time.Sleep(10 * time.Millisecond)
+	return k.KeySource.FetchJWKS(ctx)
+}
+
+func TestVerifierUnknownKeyRefreshRateLimited(t *testing.T) {
+	signer, keys := newTestSigner(t)
+	counting := &countingKeys{KeySource: keys}
+	v, err := NewVerifier(context.Background(), VerifierConfig{Keys: counting})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer v.Close()
+
+	// The initial load counts as an attempt, so wait it out once.
+	v.refreshMu.Lock()
+	v.lastAttempt = time.Time{}
+	v.refreshMu.Unlock()
+	counting.fetches.Store(0)
+
+	token := signer.signWith(t, map[string]string{"alg": "EdDSA", "kid": "rotated"}, claimsFor("u1", time.Now(), time.Minute))
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v.Verify(context.Background(), token)
+		}()
+	}
+	wg.Wait()
+	if n := counting.fetches.Load(); n != 1 {
+		t.Fatalf("%d JWKS fetches for a burst of unknown key ids, want 1", n)
+	}
+}
+
+func TestLocalFirst(t *testing.T) {
+	signer, keys := newTestSigner(t)
+	v, err := NewVerifier(context.Background(), VerifierConfig{Keys: keys})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer v.Close()
+
+	var fallbacks []string
+	fallback := sessionValidatorFunc(func(_ context.Context, token string) (*SessionResult, error) {
+		fallbacks = append(fallbacks, token)
+		return &SessionResult{Valid: true, User: &pb.User{Id: "online"}}, nil
+	})
+	lf := LocalFirst(v, fallback)
+
+	res, err := lf.ValidateSession(context.Background(), signer.sign(t, claimsFor("u1", time.Now(), time.Minute)))
+	if err != nil || res.User.GetId() != "u1" {
+		t.Fatalf("verified token: got %+v, %v", res, err)
+	}
+	res, err = lf.ValidateSession(context.Background(), "opaque-token")
+	if err != nil || res.User.GetId() != "online" {
+		t.Fatalf("unverifiable token: got %+v, %v", res, err)
+	}
+	expired := signer.sign(t, claimsFor("u1", time.Now().Add(-time.Hour), time.Minute))
+	var invalid *InvalidSessionError
+	if _, err := lf.ValidateSession(context.Background(), expired); !errors.As(err, &invalid) {
+		t.Fatalf("expired token: got %v, want InvalidSessionError", err)
+	}
+	if len(fallbacks) != 1 || fallbacks[0] != "opaque-token" {
+		t.Fatalf("fallback called with %v, want only the unverifiable token", fallbacks)
+	}
+}
+
+type sessionValidatorFunc func(ctx context.Context, token string) (*SessionResult, error)
+
+func (f sessionValidatorFunc) ValidateSession(ctx context.Context, token string) (*SessionResult, error) {
+	return f(ctx, token)
+}